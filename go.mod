@@ -17,7 +17,7 @@ require (
 	github.com/dell/gonvme v1.13.0
 	github.com/dell/goscaleio v1.22.0
 	github.com/apparentlymart/go-cidr v1.1.0
-	github.com/container-storage-interface/spec v1.7.0
+	github.com/container-storage-interface/spec v1.10.0
 	github.com/cucumber/godog v0.15.1
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/google/uuid v1.6.0
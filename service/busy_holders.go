@@ -0,0 +1,327 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/dell/csi-vxflexos/v2/k8sutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// holders reported in errors and events
+	maxReportedHolders = 10
+
+	holderOpenFile = "open file"
+	holderCwd      = "working directory"
+	holderMount    = "mount"
+
+	// ReasonVolumeBusy is the reason of the node events about volumes that can't be released
+	ReasonVolumeBusy = "VolumeBusy"
+)
+
+// procDir is where the processes holding a device are looked for. Variable so tests can use a
+// fake proc file system.
+var procDir = "/proc"
+
+var containerIDRegex = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// deviceHolder is a process keeping a device or mount point busy
+type deviceHolder struct {
+	pid         int
+	command     string
+	containerID string
+	reason      string
+}
+
+func (h deviceHolder) String() string {
+	if h.containerID != "" {
+		return fmt.Sprintf("pid %d (%s, container %s) by %s", h.pid, h.command, h.containerID, h.reason)
+	}
+	return fmt.Sprintf("pid %d (%s) by %s", h.pid, h.command, h.reason)
+}
+
+// isBusyError returns true when an unmount or disconnect failed because the device is in use
+func isBusyError(err error) bool {
+	return err != nil && (errors.Is(err, syscall.EBUSY) || strings.Contains(strings.ToLower(err.Error()), "busy"))
+}
+
+// findDeviceHolders scans the processes of the node for the device, or a mount point, opened as a
+// file or working directory, or mounted in another mount namespace. A mount namespace is reported
+// once, and not at all when it is the host's or only sees the mounts of the plugin by propagation,
+// as unmounting in the plugin's namespace removes them there too. The node plugin only sees the
+// processes of the host when it shares the host PID namespace.
+func findDeviceHolders(device string, paths []string) []deviceHolder {
+	var targets []string
+	if device != "" {
+		targets = append(targets, evalSymlinks(device))
+	}
+	for _, path := range paths {
+		if path != "" {
+			targets = append(targets, filepath.Clean(path))
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	held := func(name string) bool {
+		for _, target := range targets {
+			if name == target || strings.HasPrefix(name, target+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	selfMountNS, _ := os.Readlink(filepath.Join(procDir, "self", "ns", "mnt"))
+	hostMountNS, _ := os.Readlink(filepath.Join(procDir, "1", "ns", "mnt"))
+	selfPeerGroups := make(map[string]bool)
+	for _, groups := range heldMountPeerGroups(filepath.Join(procDir, "self", "mountinfo"), held) {
+		for _, group := range groups {
+			selfPeerGroups[group] = true
+		}
+	}
+	reportedMountNS := make(map[string]bool)
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		log.Warnf("unable to list processes: %s", err.Error())
+		return nil
+	}
+	var holders []deviceHolder
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		pidDir := filepath.Join(procDir, entry.Name())
+		reason := ""
+		if cwd, err := os.Readlink(filepath.Join(pidDir, "cwd")); err == nil && held(cwd) {
+			reason = holderCwd
+		}
+		if fds, err := os.ReadDir(filepath.Join(pidDir, "fd")); err == nil && reason == "" {
+			for _, fd := range fds {
+				if name, err := os.Readlink(filepath.Join(pidDir, "fd", fd.Name())); err == nil && held(name) {
+					reason = holderOpenFile
+					break
+				}
+			}
+		}
+		if mountNS, err := os.Readlink(filepath.Join(pidDir, "ns", "mnt")); reason == "" && err == nil &&
+			mountNS != selfMountNS && mountNS != hostMountNS && !reportedMountNS[mountNS] {
+			if mountInfoHolds(filepath.Join(pidDir, "mountinfo"), held, selfPeerGroups) {
+				reason = holderMount
+				reportedMountNS[mountNS] = true
+			}
+		}
+		if reason == "" {
+			continue
+		}
+		holders = append(holders, deviceHolder{
+			pid:         pid,
+			command:     readProcCommand(pidDir),
+			containerID: readProcContainerID(pidDir),
+			reason:      reason,
+		})
+	}
+	return holders
+}
+
+// mountInfoHolds returns true when a mount of the mountinfo file has a held mount point or source
+// and isn't propagated from one of the given peer groups
+func mountInfoHolds(mountInfo string, held func(string) bool, peerGroups map[string]bool) bool {
+	for _, groups := range heldMountPeerGroups(mountInfo, held) {
+		propagated := false
+		for _, group := range groups {
+			propagated = propagated || peerGroups[group]
+		}
+		if !propagated {
+			return true
+		}
+	}
+	return false
+}
+
+// heldMountPeerGroups returns the peer groups, shared or received from, of each mount of the
+// mountinfo file with a held mount point or source
+func heldMountPeerGroups(mountInfo string, held func(string) bool) [][]string {
+	file, err := os.Open(filepath.Clean(mountInfo))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var mounts [][]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+		isHeld := held(fields[4])
+		groups := []string{}
+		for i, field := range fields[6:] {
+			if field == "-" {
+				isHeld = isHeld || (i+8 < len(fields) && held(fields[i+8]))
+				break
+			}
+			if _, group, ok := strings.Cut(field, ":"); ok && (strings.HasPrefix(field, "shared:") || strings.HasPrefix(field, "master:")) {
+				groups = append(groups, group)
+			}
+		}
+		if isHeld {
+			mounts = append(mounts, groups)
+		}
+	}
+	return mounts
+}
+
+func readProcCommand(pidDir string) string {
+	comm, err := os.ReadFile(filepath.Join(pidDir, "comm"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// readProcContainerID returns the container ID found in the cgroup of the process, if any
+func readProcContainerID(pidDir string) string {
+	cgroup, err := os.ReadFile(filepath.Join(pidDir, "cgroup"))
+	if err != nil {
+		return ""
+	}
+	ids := containerIDRegex.FindAllString(string(cgroup), -1)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[len(ids)-1][:12]
+}
+
+// formatHolders describes the holders for an error message
+func formatHolders(holders []deviceHolder) string {
+	if len(holders) == 0 {
+		return "no process holding it was found"
+	}
+	described := make([]string, 0, maxReportedHolders)
+	for i, holder := range holders {
+		if i == maxReportedHolders {
+			described = append(described, fmt.Sprintf("and %d more", len(holders)-maxReportedHolders))
+			break
+		}
+		described = append(described, holder.String())
+	}
+	return "held by " + strings.Join(described, ", ")
+}
+
+// handleBusyUnmount explains an unmount or unstage that failed because the volume is busy. The
+// holders are reported in the returned error and in a node event. With lazy unmount enabled, the
+// mount points are detached and the release retried, but only when every holder is a mount left in
+// another mount namespace: a process with files open on the volume could still be writing to it.
+func (s *service) handleBusyUnmount(ctx context.Context, volumeID, device string, paths []string, err error, retry func() error) error {
+	if !isBusyError(err) {
+		return err
+	}
+	holders := findDeviceHolders(device, paths)
+	message := fmt.Sprintf("volume %s is busy, %s: %s", volumeID, formatHolders(holders), err.Error())
+	log.Error(message)
+
+	if s.opts.LazyUnmountOnBusy && len(holders) > 0 && onlyMountHolders(holders) {
+		for _, path := range paths {
+			if mounts, err := getPathMounts(ctx, path); err != nil || len(mounts) == 0 {
+				continue
+			}
+			log.Warnf("lazily unmounting %s of busy volume %s", path, volumeID)
+			if err := lazyUnmount(path); err != nil {
+				log.Errorf("lazy unmount of %s failed: %s", path, err.Error())
+			}
+		}
+		if err := retry(); err == nil {
+			s.createNodeEvent(corev1.EventTypeWarning, ReasonVolumeBusy, message+", lazily unmounted")
+			return nil
+		}
+	}
+
+	s.createNodeEvent(corev1.EventTypeWarning, ReasonVolumeBusy, message)
+	return status.Error(codes.Internal, message)
+}
+
+func onlyMountHolders(holders []deviceHolder) bool {
+	for _, holder := range holders {
+		if holder.reason != holderMount {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeEventRecorder records the events of the node the plugin runs on. The recorder is created at
+// the first event and aggregates the events repeated by the retries of a request into one event
+// with a count.
+type nodeEventRecorder struct {
+	sync.Mutex
+	recorder record.EventRecorder
+	nodeName string
+}
+
+// getNodeEventRecorder returns the event recorder of the node and the name of the node
+func (s *service) getNodeEventRecorder() (record.EventRecorder, string, error) {
+	s.nodeEvents.Lock()
+	defer s.nodeEvents.Unlock()
+	if s.nodeEvents.recorder != nil {
+		return s.nodeEvents.recorder, s.nodeEvents.nodeName, nil
+	}
+
+	if K8sClientset == nil {
+		if err := k8sutils.CreateKubeClientSet(); err != nil {
+			return nil, "", err
+		}
+		K8sClientset = k8sutils.Clientset
+	}
+	nodeName := s.opts.KubeNodeName
+	if nodeName == "" {
+		nodeName = os.Getenv("NODENAME")
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: K8sClientset.CoreV1().Events("")})
+	s.nodeEvents.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: Name, Host: nodeName})
+	s.nodeEvents.nodeName = nodeName
+	return s.nodeEvents.recorder, nodeName, nil
+}
+
+// createNodeEvent records an event on the node the plugin runs on
+func (s *service) createNodeEvent(eventType, reason, message string) {
+	recorder, nodeName, err := s.getNodeEventRecorder()
+	if err != nil {
+		log.Errorf("unable to create k8s clientset for node event: %v", err)
+		return
+	}
+	node := &corev1.ObjectReference{Kind: "Node", Name: nodeName, UID: types.UID(nodeName)}
+	recorder.Event(node, eventType, reason, message)
+}
@@ -0,0 +1,107 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+)
+
+const testContainerID = "3f4e5d6c7b8a99887766554433221100ffeeddccbbaa99887766554433221100"
+
+// fakeProcess creates the proc entries of a process
+func fakeProcess(t *testing.T, proc, pid, comm, mountNS, cgroup string) string {
+	pidDir := filepath.Join(proc, pid)
+	assert.NoError(t, os.MkdirAll(filepath.Join(pidDir, "fd"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(pidDir, "ns"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(pidDir, "comm"), []byte(comm+"\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(pidDir, "cgroup"), []byte(cgroup), 0o600))
+	assert.NoError(t, os.Symlink(mountNS, filepath.Join(pidDir, "ns", "mnt")))
+	assert.NoError(t, os.Symlink("/", filepath.Join(pidDir, "cwd")))
+	return pidDir
+}
+
+func TestFindDeviceHolders(t *testing.T) {
+	defer func(dir string) { procDir = dir }(procDir)
+	procDir = t.TempDir()
+
+	device := filepath.Join(t.TempDir(), "scinia")
+	assert.NoError(t, os.WriteFile(device, nil, 0o600))
+	target := "/var/lib/kubelet/pods/0d4c6d6e/volumes/kubernetes.io~csi/pvc-1/mount"
+
+	fakeProcess(t, procDir, "self", "driver", "mnt:[1]", "")
+	writer := fakeProcess(t, procDir, "100", "postgres", "mnt:[1]", "0::/kubepods/burstable/pod1/cri-containerd-"+testContainerID+".scope\n")
+	assert.NoError(t, os.Symlink(device, filepath.Join(writer, "fd", "3")))
+	leaked := fakeProcess(t, procDir, "200", "agent", "mnt:[2]", "")
+	assert.NoError(t, os.WriteFile(filepath.Join(leaked, "mountinfo"),
+		[]byte("36 35 98:0 / "+target+" rw,noatime shared:1 - ext4 /dev/scinia rw\n"), 0o600))
+	fakeProcess(t, procDir, "300", "bash", "mnt:[1]", "")
+
+	// a second process of a namespace already reported, the host namespace, and a namespace that
+	// only sees the plugin's mount by propagation are not reported
+	sibling := fakeProcess(t, procDir, "201", "agent", "mnt:[2]", "")
+	assert.NoError(t, os.WriteFile(filepath.Join(sibling, "mountinfo"),
+		[]byte("36 35 98:0 / "+target+" rw,noatime shared:1 - ext4 /dev/scinia rw\n"), 0o600))
+	host := fakeProcess(t, procDir, "1", "systemd", "mnt:[3]", "")
+	assert.NoError(t, os.WriteFile(filepath.Join(host, "mountinfo"),
+		[]byte("36 35 98:0 / "+target+" rw,noatime - ext4 /dev/scinia rw\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(procDir, "self", "mountinfo"),
+		[]byte("40 39 98:0 / "+target+" rw,noatime shared:7 - ext4 /dev/scinia rw\n"), 0o600))
+	propagated := fakeProcess(t, procDir, "400", "kubelet", "mnt:[4]", "")
+	assert.NoError(t, os.WriteFile(filepath.Join(propagated, "mountinfo"),
+		[]byte("52 51 98:0 / "+target+" rw,noatime master:7 - ext4 /dev/scinia rw\n"), 0o600))
+
+	holders := findDeviceHolders(device, []string{target})
+	assert.Equal(t, []deviceHolder{
+		{pid: 100, command: "postgres", containerID: testContainerID[:12], reason: holderOpenFile},
+		{pid: 200, command: "agent", reason: holderMount},
+	}, holders)
+	assert.False(t, onlyMountHolders(holders))
+	assert.Equal(t, "held by pid 100 (postgres, container 3f4e5d6c7b8a) by open file, pid 200 (agent) by mount", formatHolders(holders))
+}
+
+func TestIsBusyError(t *testing.T) {
+	assert.True(t, isBusyError(syscall.EBUSY))
+	assert.True(t, isBusyError(errors.New("umount: /mnt: target is busy.")))
+	assert.False(t, isBusyError(errors.New("not mounted")))
+	assert.False(t, isBusyError(nil))
+}
+
+func TestHandleBusyUnmount(t *testing.T) {
+	defer func(dir string) { procDir = dir }(procDir)
+	procDir = t.TempDir()
+
+	recorder := record.NewFakeRecorder(10)
+	s := &service{opts: Opts{KubeNodeName: "node1"}}
+	s.nodeEvents.recorder = recorder
+	s.nodeEvents.nodeName = "node1"
+	retry := func() error { return nil }
+
+	err := errors.New("not mounted")
+	assert.Equal(t, err, s.handleBusyUnmount(context.Background(), "sys1-vol1", "", []string{"/mnt"}, err, retry))
+
+	err = s.handleBusyUnmount(context.Background(), "sys1-vol1", "", []string{"/mnt"}, errors.New("target is busy"), retry)
+	assert.ErrorContains(t, err, "volume sys1-vol1 is busy, no process holding it was found")
+
+	assert.Len(t, recorder.Events, 1)
+	assert.True(t, strings.HasPrefix(<-recorder.Events, "Warning "+ReasonVolumeBusy+" volume sys1-vol1 is busy"))
+}
@@ -72,6 +72,10 @@ const (
 	// volume create parameters map
 	KeyNasName = "nasName"
 
+	// KeyNasSelectionPolicy is the key used to get the policy for choosing between
+	// several NAS servers given in nasName from the volume create parameters map
+	KeyNasSelectionPolicy = "nasSelectionPolicy"
+
 	// KeyFsType is the key used to get the filesystem type from the
 	// volume create parameters map
 	KeyFsType = "fsType"
@@ -119,6 +123,71 @@ const (
 	// VolumeIDList is the list of volume IDs
 	VolumeIDList = "VolumeIDList"
 
+	// KeySnapshotPolicy is the key used to get the name of the PowerFlex snapshot
+	// policy new volumes are assigned to from the volume create parameters map
+	KeySnapshotPolicy = "snapshotPolicy"
+
+	// KeyPruneSnapshots is the key used to get whether the oldest snapshots created by
+	// the driver may be deleted when the VTree snapshot limit is reached, from the
+	// snapshot create parameters map
+	KeyPruneSnapshots = "pruneOldestSnapshots"
+
+	// KeySnapshotAccessMode is the key used to get the access mode limit of new
+	// snapshots, ReadOnly or ReadWrite, from the snapshot create parameters map
+	KeySnapshotAccessMode = "snapshotAccessMode"
+
+	// KeySnapshotRetentionInMin is the key used to get the retention period, in
+	// minutes, of secure snapshots from the snapshot create parameters map
+	KeySnapshotRetentionInMin = "snapshotRetentionInMin"
+
+	// KeyCompressionMethod is the key used to get the compression method of new volumes,
+	// None or Normal, from the volume create parameters map. Fine granularity pools only.
+	KeyCompressionMethod = "compressionMethod"
+
+	// KeyUseRmCache is the key used to get whether new volumes use the RAM read cache
+	// of the storage pool from the volume create parameters map
+	KeyUseRmCache = "useRmcache"
+
+	// KeyVolumeAccessMode is the key used to get the access mode limit of volumes, ReadWrite or
+	// ReadOnly for volumes created from a snapshot or a clone, from the volume create parameters map
+	KeyVolumeAccessMode = "volumeAccessMode"
+
+	// KeyIopsLimitPerGiB is the key used to get the IOPS limit per GiB of volume size
+	// from the volume create parameters map
+	KeyIopsLimitPerGiB = "iopsLimitPerGiB"
+
+	// KeyMinIopsLimit is the key used to get the floor of the IOPS limit computed
+	// from iopsLimitPerGiB
+	KeyMinIopsLimit = "minIopsLimit"
+
+	// KeyMaxIopsLimit is the key used to get the ceiling of the IOPS limit computed
+	// from iopsLimitPerGiB
+	KeyMaxIopsLimit = "maxIopsLimit"
+
+	// KeyBandwidthLimitPerGiBInKbps is the key used to get the bandwidth limit per GiB
+	// of volume size from the volume create parameters map
+	KeyBandwidthLimitPerGiBInKbps = "bandwidthLimitPerGiBInKbps"
+
+	// KeyMinBandwidthLimitInKbps is the key used to get the floor of the bandwidth limit
+	// computed from bandwidthLimitPerGiBInKbps
+	KeyMinBandwidthLimitInKbps = "minBandwidthLimitInKbps"
+
+	// KeyMaxBandwidthLimitInKbps is the key used to get the ceiling of the bandwidth limit
+	// computed from bandwidthLimitPerGiBInKbps
+	KeyMaxBandwidthLimitInKbps = "maxBandwidthLimitInKbps"
+
+	// KeyPeriodicTrim is the key used to get whether the nodes periodically trim the
+	// filesystem of the volume, returning the freed blocks to the storage pool
+	KeyPeriodicTrim = "periodicTrim"
+
+	// KeyEncryption is the key used to get whether the nodes encrypt the volume, set to
+	// luks to wrap the device in dm-crypt/LUKS with the passphrase of the node stage secret
+	KeyEncryption = "encryption"
+
+	// KeyAllowFormatOverwrite is the key used to get whether the nodes may format a
+	// device holding a foreign filesystem, partition table, LVM PV or LUKS header
+	KeyAllowFormatOverwrite = "allowFormatOverwrite"
+
 	removeModeOnlyMe                    = "ONLY_ME"
 	sioGatewayNotFound                  = "Not found"
 	sioGatewayVolumeNotFound            = "Could not find the volume"
@@ -134,6 +203,14 @@ const (
 	// FALSE means "false" (comment put in for lint check)
 	FALSE = "FALSE"
 
+	// operational status of a NAS server that is serving its filesystems
+	nasServerStarted = "Started"
+
+	// states reported for a tree quota that is over one of its limits
+	treeQuotaSoftExceeded = "Soft_Exceeded"
+	treeQuotaSoftExpired  = "Soft_Exceeded_And_Expired"
+	treeQuotaHardReached  = "Hard_Reached"
+
 	sioReplicationGroupExists = "The Replication Consistency Group already exists"
 	sioReplicationPairExists  = "A Replication Pair for the specified local volume already exists"
 
@@ -162,8 +239,13 @@ const (
 )
 
 var (
-	interestingParameters = [...]string{0: "FsType", 1: KeyMkfsFormatOption, 2: KeyBandwidthLimitInKbps, 3: KeyIopsLimit}
-	log                   = csmlog.GetLogger()
+	interestingParameters = [...]string{
+		0: "FsType", 1: KeyMkfsFormatOption, 2: KeyBandwidthLimitInKbps, 3: KeyIopsLimit,
+		4: KeyIopsLimitPerGiB, 5: KeyMinIopsLimit, 6: KeyMaxIopsLimit,
+		7: KeyBandwidthLimitPerGiBInKbps, 8: KeyMinBandwidthLimitInKbps, 9: KeyMaxBandwidthLimitInKbps,
+		10: KeyPeriodicTrim, 11: KeyEncryption, 12: KeyAllowFormatOverwrite,
+	}
+	log = csmlog.GetLogger()
 )
 
 type ZoneContent struct {
@@ -209,11 +291,12 @@ func (s *service) CreateVolume(
 	cr := req.GetCapacityRange()
 
 	// Check for filesystem type
+	// SMB volumes are backed by the same filesystems as NFS volumes
 	isNFS := false
 	var fsType string
 	if len(req.VolumeCapabilities) != 0 {
 		fsType = req.VolumeCapabilities[0].GetMount().GetFsType()
-		if fsType == "nfs" {
+		if isFileFsType(fsType) {
 			isNFS = true
 		}
 	}
@@ -231,6 +314,20 @@ func (s *service) CreateVolume(
 		return nil, status.Errorf(codes.InvalidArgument, "NFS is not supported on the System %s PowerFlex version %.1f", systemID, platformInfo.ArrayVersion)
 	}
 
+	if encryption := params[KeyEncryption]; encryption != "" {
+		if !strings.EqualFold(encryption, EncryptionLUKS) {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported %s %s, only %s is supported", KeyEncryption, encryption, EncryptionLUKS)
+		}
+		if isNFS {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is not supported for %s volumes", KeyEncryption, fsType)
+		}
+	}
+
+	// snapshot policies only take snapshots of block volumes
+	if isNFS && params[KeySnapshotPolicy] != "" {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not supported for %s volumes", KeySnapshotPolicy, fsType)
+	}
+
 	remoteSystemID, ok := params[s.WithRP(KeyReplicationRemoteSystem)]
 	if ok {
 		isReplicationEnabledOnPlatform, err := s.IsReplicationEnabledOnPlatforms(systemID, remoteSystemID, platformInfo.GenType)
@@ -281,7 +378,8 @@ func (s *service) CreateVolume(
 						continue
 					}
 
-					if sourceSystemID != "" && zoneTarget.systemID != sourceSystemID {
+					// without the data mover content sources can only be used within their own system
+					if sourceSystemID != "" && zoneTarget.systemID != sourceSystemID && s.opts.DataMoverNodeID == "" {
 						continue
 					}
 
@@ -340,12 +438,16 @@ func (s *service) CreateVolume(
 				if isNFS {
 					nfsTokens := strings.Split(constraint, "-")
 					nfsLabel := ""
+					expectedLabel := "nfs"
+					if fsType == SMBFsType {
+						expectedLabel = "smb"
+					}
 					if len(nfsTokens) > 1 {
 						constraint = nfsTokens[0]
 						nfsLabel = nfsTokens[1]
-						if nfsLabel != "nfs" {
+						if nfsLabel != expectedLabel {
 							return nil, status.Errorf(codes.InvalidArgument,
-								"Invalid topology requested for NFS Volume. Please validate your storage class has nfs topology.")
+								"Invalid topology requested for NFS Volume. Please validate your storage class has %s topology.", expectedLabel)
 						}
 					}
 				}
@@ -396,7 +498,7 @@ func (s *service) CreateVolume(
 			// We need to check if user requests raw block access from nfs and prevent that
 			fsType, ok := params[KeyFsType]
 			// FsType can be empty
-			if ok && fsType == "nfs" {
+			if ok && isFileFsType(fsType) {
 				return nil, status.Errorf(codes.InvalidArgument, "raw block requested from NFS Volume")
 			}
 		}
@@ -421,7 +523,7 @@ func (s *service) CreateVolume(
 	volName := name
 
 	if isNFS {
-		// fetch NAS server ID
+		// fetch candidate NAS servers, a comma separated list may be given
 		var nasName string
 		if params[KeyNasName] != "" {
 			nasName = params[KeyNasName] // Storage class takes precedence
@@ -429,7 +531,15 @@ func (s *service) CreateVolume(
 			log.Info("nasName not present in storage class, value taken from secret")
 			nasName = arr.NasName // Secret next
 		}
-		nasServerID, err := s.getNASServerIDFromName(systemID, nasName)
+		nasPolicy, err := validateNasSelectionPolicy(params[KeyNasSelectionPolicy])
+		if err != nil {
+			return nil, err
+		}
+		system, err := s.adminClients[systemID].FindSystem(systemID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		nasServers, err := s.getNASServers(system, parseNasNames(nasName))
 		if err != nil {
 			return nil, err
 		}
@@ -473,12 +583,44 @@ func (s *service) CreateVolume(
 				return s.createVolumeFromSnapshot(req, snapshotSource, name, size, storagePoolName)
 			}
 		}
+		// Idempotency check, the filesystem may exist on any of the candidate NAS servers
+		existingFS, err := system.GetFileSystemByIDName("", volName)
+
+		if existingFS != nil {
+			existingNAS := findNASServerByID(nasServers, existingFS.NasServerID)
+			if existingNAS == nil {
+				log.Info("'Volume name' already exists on a different NAS server")
+				return nil, status.Errorf(codes.AlreadyExists, "'Volume name' already exists on NAS server %s, which is not one of %v.",
+					existingFS.NasServerID, nasServerNames(nasServers))
+			}
+			if existingFS.SizeTotal == int(size) {
+				vi := s.getCSIVolumeFromFilesystem(existingFS, systemID)
+				vi.VolumeContext[KeyNasName] = existingNAS.Name
+				vi.VolumeContext[KeyFsType] = fsType
+				vi.AccessibleTopology = s.getFilesystemTopology(systemID, fsType)
+				csiResp := &csi.CreateVolumeResponse{
+					Volume: vi,
+				}
+				log.Info("Volume exists in the requested state with same size")
+				return csiResp, nil
+			}
+			log.Info("'Volume name' already exists and size is different")
+			return nil, status.Error(codes.AlreadyExists, "'Volume name' already exists and size is different.")
+		}
+		log.Debug("Volume does not exist, proceeding to create new volume")
+
+		nas, err := s.selectNASServer(system, systemID, nasServers, nasPolicy)
+		if err != nil {
+			return nil, err
+		}
+		nasName = nas.Name
+
 		// log all parameters used in CreateVolume call
 		fields := map[string]interface{}{
 			"Name":                               volName,
 			"SizeInB":                            size,
 			"StoragePoolID":                      storagePoolID,
-			"NasServerID":                        nasServerID,
+			"NasServerID":                        nas.ID,
 			HeaderPersistentVolumeName:           params[CSIPersistentVolumeName],
 			HeaderPersistentVolumeClaimName:      params[CSIPersistentVolumeClaimName],
 			HeaderPersistentVolumeClaimNamespace: params[CSIPersistentVolumeClaimNamespace],
@@ -490,33 +632,9 @@ func (s *service) CreateVolume(
 			Name:          volName,
 			SizeTotal:     int(size),
 			StoragePoolID: storagePoolID,
-			NasServerID:   nasServerID,
+			NasServerID:   nas.ID,
 		}
 
-		// Idempotency check
-		system, err := s.adminClients[systemID].FindSystem(systemID, "", "")
-		if err != nil {
-			return nil, err
-		}
-		existingFS, err := system.GetFileSystemByIDName("", volName)
-
-		if existingFS != nil {
-			if existingFS.SizeTotal == int(size) {
-				vi := s.getCSIVolumeFromFilesystem(existingFS, systemID)
-				vi.VolumeContext[KeyNasName] = nasName
-				vi.VolumeContext[KeyFsType] = fsType
-				nfsTopology := s.GetNfsTopology(systemID)
-				vi.AccessibleTopology = nfsTopology
-				csiResp := &csi.CreateVolumeResponse{
-					Volume: vi,
-				}
-				log.Info("Volume exists in the requested state with same size")
-				return csiResp, nil
-			}
-			log.Info("'Volume name' already exists and size is different")
-			return nil, status.Error(codes.AlreadyExists, "'Volume name' already exists and size is different.")
-		}
-		log.Debug("Volume does not exist, proceeding to create new volume")
 		fsResp, err := system.CreateFileSystem(volumeParam)
 		if err != nil {
 			log.Debugf("Create volume response error:%v", err)
@@ -571,8 +689,7 @@ func (s *service) CreateVolume(
 			vi := s.getCSIVolumeFromFilesystem(newFs, systemID)
 			vi.VolumeContext[KeyNasName] = nasName
 			vi.VolumeContext[KeyFsType] = fsType
-			nfsTopology := s.GetNfsTopology(systemID)
-			vi.AccessibleTopology = nfsTopology
+			vi.AccessibleTopology = s.getFilesystemTopology(systemID, fsType)
 			csiResp := &csi.CreateVolumeResponse{
 				Volume: vi,
 			}
@@ -616,7 +733,43 @@ func (s *service) CreateVolume(
 
 		volType := s.getVolProvisionType(params) // Thick or Thin
 
+		// reject unsupported feature combinations before anything is created
+		features, err := s.validateVolumeFeatures(params, systemID, storagePool, pdID, volType, req.GetVolumeContentSource() != nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateScaledQoSParameters(req.GetParameters()); err != nil {
+			return nil, err
+		}
+
+		var snapshotPolicy *siotypes.SnapshotPolicy
+		if policyName := params[KeySnapshotPolicy]; policyName != "" {
+			snapshotPolicy, err = s.getSnapshotPolicy(systemID, policyName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		contentSource := req.GetVolumeContentSource()
+		if sourceSystemID := s.getCrossSystemSourceID(contentSource, systemID); sourceSystemID != "" {
+			copyResponse, err := s.copyVolumeAcrossSystems(ctx, req, sourceSystemID, systemID, name, size, storagePool, pdID, volType)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.setAccessModeLimit(ctx, systemID, copyResponse.Volume.VolumeId, features); err != nil {
+				return nil, err
+			}
+			if err := s.assignSnapshotPolicy(systemID, copyResponse.Volume.VolumeId, snapshotPolicy); err != nil {
+				return nil, err
+			}
+
+			copyResponse.Volume.AccessibleTopology = volumeTopology
+			if err := s.setEffectiveQoSLimits(copyResponse.Volume, req.GetParameters()); err != nil {
+				return nil, err
+			}
+
+			return copyResponse, nil
+		}
 		if contentSource != nil {
 			volumeSource := contentSource.GetVolume()
 			if volumeSource != nil {
@@ -624,8 +777,17 @@ func (s *service) CreateVolume(
 				if err != nil {
 					return nil, err
 				}
+				if err := s.setAccessModeLimit(ctx, systemID, cloneResponse.Volume.VolumeId, features); err != nil {
+					return nil, err
+				}
+				if err := s.assignSnapshotPolicy(systemID, cloneResponse.Volume.VolumeId, snapshotPolicy); err != nil {
+					return nil, err
+				}
 
 				cloneResponse.Volume.AccessibleTopology = volumeTopology
+				if err := s.setEffectiveQoSLimits(cloneResponse.Volume, req.GetParameters()); err != nil {
+					return nil, err
+				}
 
 				return cloneResponse, nil
 			}
@@ -636,8 +798,17 @@ func (s *service) CreateVolume(
 				if err != nil {
 					return nil, err
 				}
+				if err := s.setAccessModeLimit(ctx, systemID, snapshotVolumeResponse.Volume.VolumeId, features); err != nil {
+					return nil, err
+				}
+				if err := s.assignSnapshotPolicy(systemID, snapshotVolumeResponse.Volume.VolumeId, snapshotPolicy); err != nil {
+					return nil, err
+				}
 
 				snapshotVolumeResponse.Volume.AccessibleTopology = volumeTopology
+				if err := s.setEffectiveQoSLimits(snapshotVolumeResponse.Volume, req.GetParameters()); err != nil {
+					return nil, err
+				}
 
 				return snapshotVolumeResponse, nil
 			}
@@ -663,6 +834,7 @@ func (s *service) CreateVolume(
 			VolumeSizeInKb: fmt.Sprintf("%d", size),
 			VolumeType:     volType,
 		}
+		features.apply(volumeParam)
 
 		// If the VolumeParam has a MetaData method, set the values accordingly.
 		if t, ok := interface{}(volumeParam).(interface {
@@ -723,7 +895,13 @@ func (s *service) CreateVolume(
 			return nil, status.Errorf(codes.AlreadyExists,
 				"volume exists, but at different size than requested")
 		}
+		if err := s.assignSnapshotPolicy(systemID, vi.VolumeId, snapshotPolicy); err != nil {
+			return nil, err
+		}
 		copyInterestingParameters(req.GetParameters(), vi.VolumeContext)
+		if err := s.setEffectiveQoSLimits(vi, req.GetParameters()); err != nil {
+			return nil, err
+		}
 
 		log.Infof("volume %s (%s) created %s\n", vi.VolumeContext["Name"], vi.VolumeId, vi.VolumeContext["CreationTime"])
 
@@ -966,7 +1144,7 @@ func (s *service) createVolumeFromSnapshot(req *csi.CreateVolumeRequest,
 	var fsType string
 	if len(req.VolumeCapabilities) != 0 {
 		fsType = req.VolumeCapabilities[0].GetMount().GetFsType()
-		if fsType == "nfs" {
+		if isFileFsType(fsType) {
 			isNFS = true
 		}
 	}
@@ -1247,6 +1425,11 @@ func (s *service) DeleteVolume(
 
 		fsName := toBeDeletedFS.Name
 
+		// SMB shares are created by the driver at publish time and go away with the volume
+		if err := s.deleteSMBShares(ctx, systemID, toBeDeletedFS); err != nil {
+			log.Warnf("failure when removing SMB shares of fs %s: %v", fsName, err)
+		}
+
 		// Check if nfs export exists for the File system
 		client := s.adminClients[systemID]
 
@@ -1361,6 +1544,11 @@ func (s *service) DeleteVolume(
 			"volume in use by %s", vol.MappedSdcInfo[0].SdcID)
 	}
 
+	// forget an abandoned data mover copy to the volume and remove its snapshot
+	if err := s.removeDataMoverCheckpoint(systemID, vol.Name); err != nil {
+		return nil, err
+	}
+
 	// If volume is marked for replication, remove the replication pair first.
 	if vol.VolumeReplicationState != "UnmarkedForReplication" {
 		log.Infof("[DeleteVolume] - vol: %+v", vol)
@@ -1372,6 +1560,11 @@ func (s *service) DeleteVolume(
 		log.Infof("[DeleteVolume] - Removed Pair: %+v", pair)
 	}
 
+	// Detach the volume from its snapshot policy, the snapshots taken by the policy are kept
+	if err := s.unassignSnapshotPolicy(systemID, vol); err != nil {
+		return nil, err
+	}
+
 	log.WithFields(csmlog.Fields{"name": vol.Name, "id": csiVolID}).Info("Deleting volume")
 	tgtVol := goscaleio.NewVolume(s.adminClients[systemID])
 	tgtVol.Volume = vol
@@ -1520,7 +1713,7 @@ func (s *service) ControllerPublishVolume(
 	// Check for NFS protocol
 	fsType := volumeContext[KeyFsType]
 	isNFS := false
-	if fsType == "nfs" {
+	if isFileFsType(fsType) {
 		isNFS = true
 	}
 	if isNFS {
@@ -1533,6 +1726,11 @@ func (s *service) ControllerPublishVolume(
 			return nil, status.Errorf(codes.Internal, "failure checking volume status before controller publish: %s", err.Error())
 		}
 
+		// SMB access is given to each node through a share of its own
+		if fsType == SMBFsType {
+			return s.publishSMBShare(ctx, systemID, fs, nodeID, adminClient, publishContext)
+		}
+
 		var ipAddresses []string
 
 		ipAddresses, err = s.findNetworkInterfaceIPs()
@@ -1743,6 +1941,21 @@ func (s *service) ControllerUnpublishVolume(
 				"failure checking volume status before controller unpublish: %s", err.Error())
 		}
 
+		// the request does not carry the fsType: a filesystem with an NFS export is an NFS volume,
+		// otherwise it is an SMB volume when the node has a share of it
+		if _, err := s.getNFSExport(fs, adminClient); err != nil {
+			if status.Code(err) != codes.NotFound {
+				return nil, err
+			}
+			smbUnpublished, err := s.unpublishSMBShare(ctx, systemID, fs, nodeID)
+			if err != nil {
+				return nil, err
+			}
+			if smbUnpublished {
+				return &csi.ControllerUnpublishVolumeResponse{}, nil
+			}
+		}
+
 		ipAddresses, err := s.findNetworkInterfaceIPs()
 		if err != nil || len(ipAddresses) == 0 {
 			log.Infof("No network interfaces found, trying to get SDC IPs")
@@ -2022,24 +2235,28 @@ func (s *service) ListVolumes(
 			startToken = int(i)
 		}
 
-		// Call the common listVolumes code
-		source, nextToken, err = s.listVolumes(systemID, startToken, maxEntries, true, s.opts.EnableListVolumesSnapshots, "", "")
+		filesystems, err := s.listFilesystems(systemID)
 		if err != nil {
 			return nil, err
 		}
-
-		// Process the source volumes and make CSI Volumes
-		entries = make([]*csi.ListVolumesResponse_Entry, len(source))
-		i := 0
-		for _, vol := range source {
-			if vol == nil {
-				log.Infof("Volume[%d] is nil in ListVolumeResponse from system %s", i, systemID)
-				continue
-			}
-			entries[i] = &csi.ListVolumesResponse_Entry{
-				Volume: s.getCSIVolume(vol, systemID),
+		if len(filesystems) == 0 {
+			// Call the common listVolumes code
+			source, nextToken, err = s.listVolumes(systemID, startToken, maxEntries, true, s.opts.EnableListVolumesSnapshots, "", "")
+			if err != nil {
+				return nil, err
 			}
-			i = i + 1
+			entries = s.getListVolumesEntries(source, nil, systemID)
+			continue
+		}
+
+		// NFS volumes are listed after the block volumes, so page through the combined list
+		source, _, err = s.listVolumes(systemID, 0, 0, true, s.opts.EnableListVolumesSnapshots, "", "")
+		if err != nil {
+			return nil, err
+		}
+		entries, nextToken, err = paginateListVolumesEntries(s.getListVolumesEntries(source, filesystems, systemID), startToken, maxEntries)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -2049,6 +2266,78 @@ func (s *service) ListVolumes(
 	}, nil
 }
 
+// getListVolumesEntries converts the given block volumes and NFS filesystems into ListVolumes entries
+func (s *service) getListVolumesEntries(vols []*siotypes.Volume, filesystems []siotypes.FileSystem, systemID string) []*csi.ListVolumesResponse_Entry {
+	entries := make([]*csi.ListVolumesResponse_Entry, 0, len(vols)+len(filesystems))
+	for i, vol := range vols {
+		if vol == nil {
+			log.Infof("Volume[%d] is nil in ListVolumeResponse from system %s", i, systemID)
+			continue
+		}
+		entries = append(entries, &csi.ListVolumesResponse_Entry{
+			Volume: s.getCSIVolume(vol, systemID),
+		})
+	}
+	for i := range filesystems {
+		entries = append(entries, &csi.ListVolumesResponse_Entry{
+			Volume: s.getCSIVolumeFromFilesystem(&filesystems[i], systemID),
+		})
+	}
+	return entries
+}
+
+// paginateListVolumesEntries returns maxEntries entries beginning at startToken, and the next starting token.
+// The next starting token is empty once the end of the list is reached.
+func paginateListVolumesEntries(entries []*csi.ListVolumesResponse_Entry, startToken, maxEntries int) ([]*csi.ListVolumesResponse_Entry, string, error) {
+	if startToken > len(entries) {
+		return nil, "", status.Errorf(
+			codes.Aborted,
+			"startingToken=%d > len(volumes)=%d",
+			startToken, len(entries))
+	}
+
+	rem := len(entries) - startToken
+	if maxEntries == 0 || maxEntries > rem {
+		maxEntries = rem
+	}
+
+	nextToken := ""
+	if startToken+maxEntries < len(entries) {
+		nextToken = fmt.Sprintf("%d", startToken+maxEntries)
+	}
+
+	return entries[startToken : startToken+maxEntries], nextToken, nil
+}
+
+// listFilesystems returns the NFS filesystems on the given system, excluding filesystem snapshots
+// unless snapshots are listed as volumes. Systems without NFS support have no filesystems.
+func (s *service) listFilesystems(systemID string) ([]siotypes.FileSystem, error) {
+	platformInfo, err := s.GetPlatformInfo(systemID)
+	if err != nil || s.isNfsNotSupported(platformInfo.ArrayVersion) ||
+		(platformInfo.GenType != "" && s.isGenTypeNotSupportsNfsAndReplication(platformInfo.GenType)) {
+		return nil, nil
+	}
+
+	system, err := s.adminClients[systemID].FindSystem(systemID, "", "")
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to find system %s to list filesystems: %s", systemID, err.Error())
+	}
+
+	fsList, err := system.GetAllFileSystems()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to list filesystems on system %s: %s", systemID, err.Error())
+	}
+
+	filesystems := make([]siotypes.FileSystem, 0, len(fsList))
+	for _, fs := range fsList {
+		if fs.ParentID != "" && !s.opts.EnableListVolumesSnapshots {
+			continue
+		}
+		filesystems = append(filesystems, fs)
+	}
+	return filesystems, nil
+}
+
 func (s *service) ListSnapshots(
 	ctx context.Context,
 	req *csi.ListSnapshotsRequest) (
@@ -2129,20 +2418,27 @@ func (s *service) ListSnapshots(
 		return nil, err
 	}
 
-	// Process the source volumes and make CSI Volumes
+	return &csi.ListSnapshotsResponse{
+		Entries:   s.getListSnapshotsEntries(ctx, source, systemID),
+		NextToken: nextToken,
+	}, nil
+}
+
+// getListSnapshotsEntries makes the CSI snapshot entries of the snapshots, adding the expiry of the
+// retained secure snapshots to the response headers
+func (s *service) getListSnapshotsEntries(ctx context.Context, source []*siotypes.Volume, systemID string) []*csi.ListSnapshotsResponse_Entry {
 	entries := make([]*csi.ListSnapshotsResponse_Entry, len(source))
-	i := 0
-	for _, vol := range source {
+	expiries := make([]string, 0)
+	for i, vol := range source {
 		entries[i] = &csi.ListSnapshotsResponse_Entry{
 			Snapshot: s.getCSISnapshot(vol, systemID),
 		}
-		i = i + 1
+		if expiry, retained := secureSnapshotExpiry(vol); retained {
+			expiries = append(expiries, secureSnapshotExpiryValue(entries[i].Snapshot.SnapshotId, expiry))
+		}
 	}
-
-	return &csi.ListSnapshotsResponse{
-		Entries:   entries,
-		NextToken: nextToken,
-	}, nil
+	setSecureSnapshotExpiryHeader(ctx, expiries)
+	return entries
 }
 
 // Subroutine to list volumes for both CSI operations ListVolumes and ListSnapshots.
@@ -2516,6 +2812,13 @@ func (s *service) ControllerGetCapabilities(
 				},
 			},
 		},
+		{ // Required for ControllerModifyVolume
+			Type: &csi.ControllerServiceCapability_Rpc{
+				Rpc: &csi.ControllerServiceCapability_RPC{
+					Type: csi.ControllerServiceCapability_RPC_MODIFY_VOLUME,
+				},
+			},
+		},
 		{ // Indicates PowerFlex supports SINGLE_NODE_SINGLE_WRITER and/or SINGLE_NODE_MULTI_WRITER access modes
 			Type: &csi.ControllerServiceCapability_Rpc{
 				Rpc: &csi.ControllerServiceCapability_RPC{
@@ -3048,6 +3351,7 @@ func (s *service) CreateSnapshot(
 	}
 	snapDef := siotypes.SnapshotDef{VolumeID: volID, SnapshotName: snapName}
 	snapshotDefs = append(snapshotDefs, &snapDef)
+	snapSources := []*siotypes.Volume{vol}
 
 	// Determine if we want to add additional volumes to a consistency group
 	// volIDList should be in PowerFlex format, or CSI format
@@ -3076,17 +3380,32 @@ func (s *service) CreateSnapshot(
 			snapName = generateSnapName(volx.Name)
 			snapshotDefX := siotypes.SnapshotDef{VolumeID: vID, SnapshotName: snapName}
 			snapshotDefs = append(snapshotDefs, &snapshotDefX)
+			snapSources = append(snapSources, volx)
 		}
 	}
 
+	accessMode, retentionInMin, err := getSnapshotProtection(req.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure there is room in the VTree of every source volume
+	prune := strings.EqualFold(req.Parameters[KeyPruneSnapshots], "true")
+	if err := s.ensureVTreeSnapshotCapacity(systemID, snapSources, prune); err != nil {
+		return nil, err
+	}
+
 	// Create snapshot(s)
 	snapResponse := &siotypes.SnapshotVolumesResp{}
 	if vol.GenType == "EC" {
+		if retentionInMin != "" {
+			return nil, status.Errorf(codes.InvalidArgument, "secure snapshots are not supported on the System %s GenType %s", systemID, vol.GenType)
+		}
 		snapParam := &siotypes.CreateSnapshotParam{SnapshotDefs: snapshotDefs}
 		system := s.systems[systemID]
 		snapResponse, err = createSnapshotFunc(system, snapParam)
 	} else {
-		snapParam := &siotypes.SnapshotVolumesParam{SnapshotDefs: snapshotDefs, AccessMode: "ReadOnly"}
+		snapParam := &siotypes.SnapshotVolumesParam{SnapshotDefs: snapshotDefs, AccessMode: accessMode, RetentionPeriodInMin: retentionInMin}
 		snapResponse, err = s.systems[systemID].CreateSnapshotConsistencyGroup(snapParam)
 	}
 	if err != nil {
@@ -3219,6 +3538,12 @@ func (s *service) DeleteSnapshot(
 		return nil, status.Errorf(codes.FailedPrecondition, "snapshot is in use by the following SDC IP addresses: %s", ips)
 	}
 
+	// Secure snapshots can not be removed until their retention period is over
+	if expiry, retained := secureSnapshotExpiry(vol); retained {
+		return nil, status.Errorf(codes.FailedPrecondition, "snapshot %s is a secure snapshot retained until %s",
+			csiSnapID, expiry.UTC().Format(time.RFC3339))
+	}
+
 	adminClient := s.adminClients[systemID]
 
 	// Check for consistency group delete, and it must be globally enabled as startup option,
@@ -3248,6 +3573,7 @@ func (s *service) DeleteSnapshotConsistencyGroup(
 ) {
 	cgVols := make([]*siotypes.Volume, 0)
 	exposedVols := make([]string, 0)
+	retainedVols := make([]string, 0)
 	cgID := snapVol.ConsistencyGroupID
 	//
 	log.Infof("Called DeleteSnapshotConsistencyGroup id: cg %s\n", cgID)
@@ -3263,6 +3589,9 @@ func (s *service) DeleteSnapshotConsistencyGroup(
 			if len(vol.MappedSdcInfo) > 0 {
 				exposedVols = append(exposedVols, fmt.Sprintf("%s (%s) ", vol.Name, vol.ID))
 			}
+			if expiry, retained := secureSnapshotExpiry(vol); retained {
+				retainedVols = append(retainedVols, fmt.Sprintf("%s (%s) until %s ", vol.Name, vol.ID, expiry.UTC().Format(time.RFC3339)))
+			}
 		}
 	}
 
@@ -3271,6 +3600,9 @@ func (s *service) DeleteSnapshotConsistencyGroup(
 	if len(exposedVols) > 0 {
 		return nil, status.Errorf(codes.FailedPrecondition, "One or more consistency group volumes are exposed and may be in use: %v", exposedVols)
 	}
+	if len(retainedVols) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "One or more consistency group volumes are secure snapshots still retained: %v", retainedVols)
+	}
 	// If there are no volumes, at least add the original one passed in.
 	if len(cgVols) == 0 {
 		log.Infof("Name %s CG %s ID %s", snapVol.Name, snapVol.ConsistencyGroupID, snapVol.ID)
@@ -3392,8 +3724,8 @@ func (s *service) ControllerExpandVolume(ctx context.Context, req *csi.Controlle
 				return nil, status.Error(codes.Internal, err.Error())
 			}
 
-			// Modify Tree Quota
-			updatedSoftLimit := treeQuota.SoftLimit * (requestedSize / treeQuota.HardLimit)
+			// Modify Tree Quota, keeping the soft limit at the same percentage of the hard limit
+			updatedSoftLimit := scaleSoftLimit(treeQuota.SoftLimit, treeQuota.HardLimit, requestedSize)
 			treeQuotaID := treeQuota.ID
 			log.Infof("Modifying tree quota ID %s for NFS volume ID: %s", treeQuotaID, fsID)
 			quotaModify := &siotypes.TreeQuotaModify{
@@ -3464,6 +3796,10 @@ func (s *service) ControllerExpandVolume(ctx context.Context, req *csi.Controlle
 	if requestedSize == allocatedSize {
 		log.Infof("Idempotent call detected for volume (%s) with requested size (%d) SizeInKb and allocated size (%d) SizeInKb",
 			volName, requestedSize, allocatedSize)
+		// a retry after the resize may still have to set the scaled QoS limits
+		if err := s.refreshScaledQoS(ctx, systemID, csiVolID, vol, requestedSize); err != nil {
+			return nil, err
+		}
 		return &csi.ControllerExpandVolumeResponse{
 			CapacityBytes:         requestedSize * bytesInKiB,
 			NodeExpansionRequired: true,
@@ -3489,6 +3825,11 @@ func (s *service) ControllerExpandVolume(ctx context.Context, req *csi.Controlle
 		}
 	}
 
+	// limits given per GiB follow the new size on every host the volume is mapped to
+	if err := s.refreshScaledQoS(ctx, systemID, csiVolID, vol, requestedSize); err != nil {
+		return nil, err
+	}
+
 	// return the response with NodeExpansionRequired = true, so that CO could call
 	// NodeExpandVolume subsequently
 	csiResp := &csi.ControllerExpandVolumeResponse{
@@ -3498,6 +3839,139 @@ func (s *service) ControllerExpandVolume(ctx context.Context, req *csi.Controlle
 	return csiResp, nil
 }
 
+// scaleSoftLimit returns the soft limit for newHardLimit that keeps the same percentage
+// of the hard limit as softLimit is of hardLimit.
+func scaleSoftLimit(softLimit, hardLimit, newHardLimit int) int {
+	if hardLimit <= 0 {
+		return softLimit
+	}
+	return int(int64(softLimit) * int64(newHardLimit) / int64(hardLimit))
+}
+
+// ControllerModifyVolume applies the mutable parameters of a VolumeAttributesClass to a volume.
+// For NFS volumes with a tree quota, the soft limit (as a percentage of the hard limit) and the
+// grace period (in seconds) of the quota can be modified. For block volumes, a new storage pool
+// and protection domain start an online migration of the volume's VTree.
+func (s *service) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
+	log := log.WithContext(ctx)
+	log.Infof("[ControllerModifyVolume] req: %+v", req)
+
+	csiVolID := req.GetVolumeId()
+	if csiVolID == "" {
+		return nil, status.Error(codes.InvalidArgument,
+			"volume ID is required")
+	}
+
+	params := req.GetMutableParameters()
+	if len(params) == 0 {
+		return nil, status.Error(codes.InvalidArgument,
+			"mutable parameters are required")
+	}
+
+	systemID := s.getSystemIDFromCsiVolumeID(csiVolID)
+	if systemID == "" {
+		// use default system
+		systemID = s.opts.defaultSystemID
+	}
+	if systemID == "" {
+		return nil, status.Error(codes.InvalidArgument,
+			"systemID is not found in the request and there is no default system")
+	}
+
+	if err := s.requireProbe(ctx, systemID); err != nil {
+		return nil, err
+	}
+
+	isNFS := strings.Contains(csiVolID, "/")
+	if !isNFS {
+		if err := s.migrateBlockVolume(ctx, systemID, csiVolID, params); err != nil {
+			return nil, err
+		}
+		return &csi.ControllerModifyVolumeResponse{}, nil
+	}
+
+	for key := range params {
+		if key != KeySoftLimit && key != KeyGracePeriod {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"unsupported mutable parameter %s for NFS volume %s", key, csiVolID)
+		}
+	}
+
+	fsID := getFilesystemIDFromCsiVolumeID(csiVolID)
+	fs, err := s.getFilesystemByID(fsID, systemID)
+	if err != nil {
+		if strings.EqualFold(err.Error(), sioGatewayFileSystemNotFound) || strings.Contains(err.Error(), "must be a hexadecimal number") {
+			return nil, status.Error(codes.NotFound, "volume not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failure to load volume: %s", err.Error())
+	}
+
+	if !s.opts.IsQuotaEnabled || !fs.IsQuotaEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"quota is not enabled for NFS volume %s", csiVolID)
+	}
+
+	if err := s.modifyQuota(systemID, fs, params[KeySoftLimit], params[KeyGracePeriod]); err != nil {
+		return nil, err
+	}
+
+	return &csi.ControllerModifyVolumeResponse{}, nil
+}
+
+// modifyQuota updates the soft limit percentage and/or grace period of the tree quota of the given
+// filesystem. Empty values leave the corresponding quota setting unchanged.
+func (s *service) modifyQuota(systemID string, fs *siotypes.FileSystem, softLimit, gracePeriod string) error {
+	system, err := s.adminClients[systemID].FindSystem(systemID, "", "")
+	if err != nil {
+		return err
+	}
+
+	treeQuota, err := system.GetTreeQuotaByFSID(fs.ID)
+	if err != nil {
+		log.Errorf("Fetching tree quota for NFS volume failed, error: %s", err.Error())
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	// start from the current settings, so changing only one of them does not reset the other
+	quotaModify := &siotypes.TreeQuotaModify{
+		SoftLimit:   treeQuota.SoftLimit,
+		GracePeriod: treeQuota.GracePeriod,
+	}
+
+	if softLimit != "" {
+		softLimitPerc, err := strconv.ParseInt(softLimit, 10, 64)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "requested softLimit: %s is not numeric for volume %s, error: %s", softLimit, fs.ID, err)
+		}
+		softLimitInt := (softLimitPerc * int64(treeQuota.HardLimit)) / 100
+		if softLimitInt <= 0 || int(softLimitInt) >= treeQuota.HardLimit {
+			return status.Errorf(codes.InvalidArgument, "requested softLimit: %s perc must be greater than 0 and less than 100 for volume %s", softLimit, fs.ID)
+		}
+		quotaModify.SoftLimit = int(softLimitInt)
+	}
+
+	if gracePeriod != "" {
+		gracePeriodInt, err := strconv.ParseInt(gracePeriod, 10, 64)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "requested gracePeriod: %s is not numeric for volume %s, error: %s", gracePeriod, fs.ID, err)
+		}
+		quotaModify.GracePeriod = int(gracePeriodInt)
+	}
+
+	fields := map[string]interface{}{
+		"TreeQuotaID": treeQuota.ID,
+		"SoftLimit":   quotaModify.SoftLimit,
+		"GracePeriod": quotaModify.GracePeriod,
+	}
+	log.WithFields(fields).Info("Executing ModifyTreeQuota with following fields")
+
+	if err := system.ModifyTreeQuota(quotaModify, treeQuota.ID); err != nil {
+		log.Errorf("Modifying tree quota for NFS volume failed, error: %s", err.Error())
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
 // mergeStringMaps adds two string to string maps together
 func mergeStringMaps(base map[string]string, additional map[string]string) map[string]string {
 	result := make(map[string]string)
@@ -3619,7 +4093,7 @@ func (s *service) Clone(req *csi.CreateVolumeRequest,
 
 // ControllerGetVolume fetch current information about a volume
 // returns volume condition if found else returns not found
-func (s *service) ControllerGetVolume(_ context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+func (s *service) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
 	abnormal := false
 	csiVolID := req.GetVolumeId()
 	if csiVolID == "" {
@@ -3637,6 +4111,10 @@ func (s *service) ControllerGetVolume(_ context.Context, req *csi.ControllerGetV
 			"systemID is not found in the request and there is no default system")
 	}
 
+	if strings.Contains(csiVolID, "/") {
+		return s.controllerGetNFSVolume(ctx, csiVolID, systemID)
+	}
+
 	vol, err := s.getVolByID(volID, systemID)
 	if err != nil {
 		if strings.EqualFold(err.Error(), sioGatewayVolumeNotFound) {
@@ -3656,17 +4134,128 @@ func (s *service) ControllerGetVolume(_ context.Context, req *csi.ControllerGetV
 			err.Error())
 	}
 
+	condition := &csi.VolumeCondition{
+		Abnormal: abnormal,
+		Message:  "Volume is in good condition",
+	}
+	// report the progress of a storage pool migration, the volume context shows the new pool once it completes
+	if migrationCondition, migrating := s.getMigrationCondition(systemID, vol); migrating {
+		condition = migrationCondition
+	}
+
 	csiResp := &csi.ControllerGetVolumeResponse{
 		Volume: s.getCSIVolume(vol, systemID),
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			VolumeCondition: condition,
+		},
+	}
+
+	return csiResp, nil
+}
+
+// controllerGetNFSVolume is the ControllerGetVolume path for NFS volumes (csiVolID of the form systemID/fsID)
+func (s *service) controllerGetNFSVolume(ctx context.Context, csiVolID, systemID string) (*csi.ControllerGetVolumeResponse, error) {
+	fsID := getFilesystemIDFromCsiVolumeID(csiVolID)
+	fs, err := s.getFilesystemByID(fsID, systemID)
+	if err != nil {
+		if strings.EqualFold(err.Error(), sioGatewayFileSystemNotFound) || strings.Contains(err.Error(), sioGatewayNotFound) {
+			message := fmt.Sprintf("Filesystem is not found by controller at %s", time.Now().Format("2006-01-02 15:04:05"))
+			return &csi.ControllerGetVolumeResponse{
+				Volume: nil,
+				Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+					VolumeCondition: &csi.VolumeCondition{
+						Abnormal: true,
+						Message:  message,
+					},
+				},
+			}, nil
+		}
+		return nil, status.Errorf(codes.Internal,
+			"Volume status could not be determined: %s",
+			err.Error())
+	}
+
+	message, err := s.getNFSVolumeCondition(ctx, systemID, fs)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"Volume status could not be determined: %s",
+			err.Error())
+	}
+	abnormal := message != ""
+	if !abnormal {
+		message = "Volume is in good condition"
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: s.getCSIVolumeFromFilesystem(fs, systemID),
 		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
 			VolumeCondition: &csi.VolumeCondition{
 				Abnormal: abnormal,
-				Message:  "Volume is in good condition",
+				Message:  message,
 			},
 		},
+	}, nil
+}
+
+// getNFSVolumeCondition checks the NFS export or SMB shares, NAS server and tree quota of the given filesystem.
+// It returns a message describing the first problem found, or an empty message if the volume is healthy.
+func (s *service) getNFSVolumeCondition(ctx context.Context, systemID string, fs *siotypes.FileSystem) (string, error) {
+	client := s.adminClients[systemID]
+	if client == nil {
+		return "", fmt.Errorf("can't find adminClient by id %s", systemID)
 	}
 
-	return csiResp, nil
+	if _, err := s.getNFSExport(fs, client); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return "", err
+		}
+		// SMB volumes have a share for each node they are published to instead of an NFS export
+		shares, err := s.getSMBShares(ctx, systemID, fs)
+		if err != nil {
+			log.Debugf("unable to get the SMB shares of filesystem: %s: %s", fs.Name, err.Error())
+		}
+		if len(shares) == 0 {
+			return fmt.Sprintf("NFS export or SMB share for filesystem: %s is missing", fs.Name), nil
+		}
+	}
+
+	system, err := client.FindSystem(systemID, "", "")
+	if err != nil {
+		return "", err
+	}
+
+	nas, err := system.GetNASByIDName(fs.NasServerID, "")
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(nas.OperationalStatus, nasServerStarted) {
+		return fmt.Sprintf("NAS server: %s is offline, operational status: %s", nas.Name, nas.OperationalStatus), nil
+	}
+
+	if s.opts.IsQuotaEnabled && fs.IsQuotaEnabled {
+		treeQuota, err := system.GetTreeQuotaByFSID(fs.ID)
+		if err != nil {
+			return "", err
+		}
+		if message := treeQuotaCondition(treeQuota); message != "" {
+			return message, nil
+		}
+	}
+
+	return "", nil
+}
+
+// treeQuotaCondition returns a message describing an exceeded tree quota, or an empty message
+// if the quota is within its limits.
+func treeQuotaCondition(treeQuota *siotypes.TreeQuota) string {
+	switch {
+	case strings.EqualFold(treeQuota.State, treeQuotaHardReached) || (treeQuota.HardLimit > 0 && treeQuota.SizeUsed >= treeQuota.HardLimit):
+		return fmt.Sprintf("tree quota: %s hard limit exceeded, used: %d bytes, hard limit: %d bytes", treeQuota.ID, treeQuota.SizeUsed, treeQuota.HardLimit)
+	case strings.EqualFold(treeQuota.State, treeQuotaSoftExceeded) || strings.EqualFold(treeQuota.State, treeQuotaSoftExpired):
+		return fmt.Sprintf("tree quota: %s soft limit exceeded, used: %d bytes, soft limit: %d bytes, remaining grace period: %d seconds",
+			treeQuota.ID, treeQuota.SizeUsed, treeQuota.SoftLimit, treeQuota.RemainingGracePeriod)
+	}
+	return ""
 }
 
 func (s *service) CreateReplicationConsistencyGroup(systemID string, name string,
@@ -817,3 +817,91 @@ func TestParseScopes(t *testing.T) {
 		})
 	}
 }
+
+func TestPaginateListVolumesEntries(t *testing.T) {
+	entries := make([]*csi.ListVolumesResponse_Entry, 5)
+	for i := range entries {
+		entries[i] = &csi.ListVolumesResponse_Entry{Volume: &csi.Volume{VolumeId: fmt.Sprintf("vol-%d", i)}}
+	}
+
+	cases := []struct {
+		name       string
+		startToken int
+		maxEntries int
+		wantLen    int
+		wantNext   string
+		wantErr    bool
+	}{
+		{name: "all entries", startToken: 0, maxEntries: 0, wantLen: 5, wantNext: ""},
+		{name: "first page", startToken: 0, maxEntries: 2, wantLen: 2, wantNext: "2"},
+		{name: "last page", startToken: 4, maxEntries: 2, wantLen: 1, wantNext: ""},
+		{name: "exact end", startToken: 3, maxEntries: 2, wantLen: 2, wantNext: ""},
+		{name: "token past end", startToken: 6, maxEntries: 2, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, next, err := paginateListVolumesEntries(entries, tc.startToken, tc.maxEntries)
+			if tc.wantErr {
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("expected Aborted error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != tc.wantLen || next != tc.wantNext {
+				t.Fatalf("want %d entries and next token %q, got %d and %q", tc.wantLen, tc.wantNext, len(got), next)
+			}
+		})
+	}
+}
+
+func TestTreeQuotaCondition(t *testing.T) {
+	cases := []struct {
+		name      string
+		quota     *siotypes.TreeQuota
+		wantMatch string
+	}{
+		{name: "within limits", quota: &siotypes.TreeQuota{State: "OK", HardLimit: 100, SizeUsed: 10}, wantMatch: ""},
+		{name: "soft limit exceeded", quota: &siotypes.TreeQuota{State: treeQuotaSoftExceeded, HardLimit: 100, SizeUsed: 90}, wantMatch: "soft limit exceeded"},
+		{name: "grace period expired", quota: &siotypes.TreeQuota{State: treeQuotaSoftExpired, HardLimit: 100, SizeUsed: 90}, wantMatch: "soft limit exceeded"},
+		{name: "hard limit reached", quota: &siotypes.TreeQuota{State: treeQuotaHardReached, HardLimit: 100, SizeUsed: 100}, wantMatch: "hard limit exceeded"},
+		{name: "usage at hard limit", quota: &siotypes.TreeQuota{State: "OK", HardLimit: 100, SizeUsed: 100}, wantMatch: "hard limit exceeded"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := treeQuotaCondition(tc.quota)
+			if tc.wantMatch == "" && got != "" {
+				t.Fatalf("expected no condition, got %q", got)
+			}
+			if !strings.Contains(got, tc.wantMatch) {
+				t.Fatalf("expected condition to contain %q, got %q", tc.wantMatch, got)
+			}
+		})
+	}
+}
+
+func TestScaleSoftLimit(t *testing.T) {
+	cases := []struct {
+		name         string
+		softLimit    int
+		hardLimit    int
+		newHardLimit int
+		want         int
+	}{
+		{name: "double the size", softLimit: 80, hardLimit: 100, newHardLimit: 200, want: 160},
+		{name: "size not a multiple", softLimit: 4 * bytesInGiB, hardLimit: 5 * bytesInGiB, newHardLimit: 8 * bytesInGiB, want: 32 * bytesInGiB / 5},
+		{name: "no hard limit", softLimit: 80, hardLimit: 0, newHardLimit: 200, want: 80},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := scaleSoftLimit(tc.softLimit, tc.hardLimit, tc.newHardLimit); got != tc.want {
+				t.Fatalf("want soft limit %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,548 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/dell/goscaleio"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The data mover copies the content source of a new volume when the source lives on a different
+// system than the one the volume is created on. The controller maps the source and the new volume
+// to the data mover node, which must be the node the controller runs on, and copies the blocks
+// in the background. CreateVolume returns Aborted with the progress until the copy is done, so
+// the provisioner keeps retrying. Progress is checkpointed to the state directory, and the
+// checkpoint records that the copy is done until the volume is deleted, so a CreateVolume retried
+// after a controller restart returns the volume instead of copying it again. An interrupted copy
+// resumes from the last checkpoint when the state directory is on storage that outlives the
+// controller container, otherwise the copy starts over: the new volume is not returned, so nothing
+// uses it before the copy is done. samples/datamover/controller-patch.yaml lists what the
+// controller deployment needs to run the data mover.
+const (
+	// dataMoverChunkSize is the size of each read and write of the copy
+	dataMoverChunkSize = 4 * 1024 * 1024
+
+	// dataMoverCheckpointBytes is the amount of data copied between two checkpoints
+	dataMoverCheckpointBytes = 1024 * 1024 * 1024
+
+	// dataMoverSnapshotPrefix names the snapshot taken of a source volume to copy a consistent image
+	dataMoverSnapshotPrefix = "dm-"
+
+	// dataMoverDeviceRetries is the number of times the device of a mapped volume is looked up
+	dataMoverDeviceRetries = 30
+
+	defaultDataMoverStateDir = "/var/lib/csi-powerflex/datamover"
+
+	nvmeDiskByIDPrefix = "/dev/disk/by-id/nvme-eui."
+)
+
+var dataMoverDeviceDelay = 2 * time.Second
+
+// dataMoverCheckpoint is the persisted state of a copy
+type dataMoverCheckpoint struct {
+	SourceSystemID string `json:"sourceSystemID"`
+	SourceID       string `json:"sourceID"`
+	// CopySourceID is the source snapshot, or the snapshot taken of the source volume
+	CopySourceID   string `json:"copySourceID,omitempty"`
+	TempSnapshot   bool   `json:"tempSnapshot,omitempty"`
+	TargetSystemID string `json:"targetSystemID"`
+	TargetName     string `json:"targetName"`
+	TargetVolumeID string `json:"targetVolumeID"`
+	SizeBytes      int64  `json:"sizeBytes"`
+	CopiedBytes    int64  `json:"copiedBytes"`
+	Completed      bool   `json:"completed,omitempty"`
+}
+
+// dataMoverJob tracks a copy running in this controller
+type dataMoverJob struct {
+	targetVolumeID string
+	size           int64
+	copied         atomic.Int64
+	done           chan struct{}
+	err            error
+}
+
+// progress returns the percentage of the volume copied so far
+func (j *dataMoverJob) progress() int64 {
+	if j.size == 0 {
+		return 100
+	}
+	return j.copied.Load() * 100 / j.size
+}
+
+// getCrossSystemSourceID returns the system of the content source when it differs from systemID
+// and the data mover is configured, otherwise an empty string
+func (s *service) getCrossSystemSourceID(contentSource *csi.VolumeContentSource, systemID string) string {
+	if s.opts.DataMoverNodeID == "" || contentSource == nil {
+		return ""
+	}
+	sourceID := contentSource.GetVolume().GetVolumeId()
+	if snapshotSource := contentSource.GetSnapshot(); snapshotSource != nil {
+		sourceID = snapshotSource.GetSnapshotId()
+	}
+	if sourceID == "" {
+		return ""
+	}
+	sourceSystemID := s.getSystemIDFromCsiVolumeID(sourceID)
+	if sourceSystemID == "" {
+		sourceSystemID = s.opts.defaultSystemID
+	}
+	if sourceSystemID == systemID {
+		return ""
+	}
+	return sourceSystemID
+}
+
+// copyVolumeAcrossSystems creates the volume on systemID and copies the content source from
+// sourceSystemID into it through the data mover node
+func (s *service) copyVolumeAcrossSystems(ctx context.Context, req *csi.CreateVolumeRequest, sourceSystemID string,
+	systemID string, name string, sizeInKbytes int64, storagePool string, pdID string, volType string,
+) (*csi.CreateVolumeResponse, error) {
+	if err := s.requireProbe(ctx, sourceSystemID); err != nil {
+		return nil, err
+	}
+
+	contentSource := req.GetVolumeContentSource()
+	isSnapshot := contentSource.GetSnapshot() != nil
+	sourceCsiID := contentSource.GetVolume().GetVolumeId()
+	if isSnapshot {
+		sourceCsiID = contentSource.GetSnapshot().GetSnapshotId()
+	}
+	sourceID := getVolumeIDFromCsiVolumeID(sourceCsiID)
+	srcVol, err := s.getVolByID(sourceID, sourceSystemID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Volume content source not found: %s, error: %s", sourceCsiID, err.Error())
+	}
+	if int64(srcVol.SizeInKb) > sizeInKbytes {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"Volume content source %s of %d kbytes does not fit in the requested %d kbytes",
+			sourceCsiID, srcVol.SizeInKb, sizeInKbytes)
+	}
+
+	key := systemID + "-" + name
+	if value, ok := s.dataMoverJobs.Load(key); ok {
+		job := value.(*dataMoverJob)
+		select {
+		case <-job.done:
+			s.dataMoverJobs.Delete(key)
+			if job.err != nil {
+				return nil, status.Errorf(codes.Internal, "copy of %s to volume %s failed, it resumes on the next request: %s",
+					sourceCsiID, name, job.err.Error())
+			}
+			return s.getCopiedVolume(req, systemID, job.targetVolumeID, sourceCsiID)
+		default:
+			return nil, status.Errorf(codes.Aborted, "copy of %s to volume %s in progress: %d%% done", sourceCsiID, name, job.progress())
+		}
+	}
+
+	cp, err := s.loadDataMoverCheckpoint(systemID, name)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not read data mover checkpoint of volume %s: %s", name, err.Error())
+	}
+	if cp == nil {
+		targetID, err := s.createDataMoverTarget(systemID, name, sizeInKbytes, storagePool, pdID, volType)
+		if err != nil {
+			return nil, err
+		}
+		cp = &dataMoverCheckpoint{
+			SourceSystemID: sourceSystemID,
+			SourceID:       sourceID,
+			TargetSystemID: systemID,
+			TargetName:     name,
+			TargetVolumeID: targetID,
+			SizeBytes:      int64(srcVol.SizeInKb) * bytesInKiB,
+		}
+		// a snapshot is consistent already, a volume is snapshotted before the copy starts
+		if isSnapshot {
+			cp.CopySourceID = sourceID
+		}
+		if err := s.saveDataMoverCheckpoint(cp); err != nil {
+			return nil, status.Errorf(codes.Internal, "could not write data mover checkpoint of volume %s: %s", name, err.Error())
+		}
+	} else if cp.SourceSystemID != sourceSystemID || cp.SourceID != sourceID {
+		return nil, status.Errorf(codes.AlreadyExists, "volume %s is already being copied from %s-%s", name, cp.SourceSystemID, cp.SourceID)
+	} else if cp.Completed {
+		return s.getCopiedVolume(req, systemID, cp.TargetVolumeID, sourceCsiID)
+	}
+
+	job := &dataMoverJob{targetVolumeID: cp.TargetVolumeID, size: cp.SizeBytes, done: make(chan struct{})}
+	job.copied.Store(cp.CopiedBytes)
+	if _, loaded := s.dataMoverJobs.LoadOrStore(key, job); !loaded {
+		log.Infof("Data mover copying %s to volume %s on system %s from byte %d of %d",
+			sourceCsiID, name, systemID, cp.CopiedBytes, cp.SizeBytes)
+		go func() {
+			job.err = s.runDataMover(context.Background(), cp, srcVol.GenType, job)
+			if job.err != nil {
+				log.Errorf("Data mover copy of %s to volume %s failed: %s", sourceCsiID, name, job.err.Error())
+			}
+			close(job.done)
+		}()
+	}
+	return nil, status.Errorf(codes.Aborted, "copy of %s to volume %s in progress: %d%% done", sourceCsiID, name, job.progress())
+}
+
+// getCopiedVolume returns the CreateVolume response of a volume the data mover is done copying to
+func (s *service) getCopiedVolume(req *csi.CreateVolumeRequest, systemID, volID, sourceCsiID string) (*csi.CreateVolumeResponse, error) {
+	vol, err := s.getVolByID(volID, systemID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not retrieve copied volume: %s, error: %s", volID, err.Error())
+	}
+	s.clearCache()
+	csiVolume := s.getCSIVolume(vol, systemID)
+	csiVolume.ContentSource = req.GetVolumeContentSource()
+	copyInterestingParameters(req.GetParameters(), csiVolume.VolumeContext)
+
+	log.Infof("Volume (copied from %s) %s (%s) storage pool %s",
+		sourceCsiID, csiVolume.VolumeContext["Name"], csiVolume.VolumeId, csiVolume.VolumeContext["StoragePoolName"])
+	return &csi.CreateVolumeResponse{Volume: csiVolume}, nil
+}
+
+// createDataMoverTarget creates the volume the content source is copied to, or returns the volume
+// left by an earlier attempt
+func (s *service) createDataMoverTarget(systemID, name string, sizeInKbytes int64, storagePool, pdID, volType string) (string, error) {
+	volumeParam := &siotypes.VolumeParam{
+		Name:           name,
+		VolumeSizeInKb: fmt.Sprintf("%d", sizeInKbytes),
+		VolumeType:     volType,
+	}
+	createResp, err := s.adminClients[systemID].CreateVolume(volumeParam, storagePool, pdID)
+	if err == nil {
+		return createResp.ID, nil
+	}
+	if !strings.EqualFold(err.Error(), sioGatewayVolumeNameInUse) {
+		return "", status.Errorf(codes.Internal,
+			"error when creating volume %s storagepool %s: %s", name, storagePool, err.Error())
+	}
+	id, err := s.adminClients[systemID].FindVolumeID(name)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "%s", err.Error())
+	}
+	return id, nil
+}
+
+// runDataMover snapshots a source volume if needed, copies it to the target volume, records the
+// copy as completed and removes the snapshot
+func (s *service) runDataMover(ctx context.Context, cp *dataMoverCheckpoint, genType string, job *dataMoverJob) error {
+	if cp.CopySourceID == "" {
+		snapID, err := s.createDataMoverSnapshot(cp.SourceSystemID, cp.SourceID, cp.TargetName, genType)
+		if err != nil {
+			return err
+		}
+		cp.CopySourceID = snapID
+		cp.TempSnapshot = true
+		if err := s.saveDataMoverCheckpoint(cp); err != nil {
+			return err
+		}
+	}
+
+	if err := s.copyThroughDataMover(ctx, cp, job); err != nil {
+		return err
+	}
+
+	log.Infof("Data mover copied %d bytes to volume %s on system %s", cp.SizeBytes, cp.TargetName, cp.TargetSystemID)
+
+	// the checkpoint is kept until the volume is deleted, a restart must not copy it again
+	cp.Completed = true
+	if err := s.saveDataMoverCheckpoint(cp); err != nil {
+		return err
+	}
+	if cp.TempSnapshot {
+		if err := s.removeDataMoverSnapshot(cp.SourceSystemID, cp.CopySourceID); err != nil {
+			log.Warnf("could not remove data mover snapshot %s on system %s: %s", cp.CopySourceID, cp.SourceSystemID, err.Error())
+			return nil
+		}
+		cp.TempSnapshot = false
+		if err := s.saveDataMoverCheckpoint(cp); err != nil {
+			log.Warnf("could not write data mover checkpoint of volume %s: %s", cp.TargetName, err.Error())
+		}
+	}
+	return nil
+}
+
+// copyThroughDataMover maps the copy source and target to the data mover node and copies the blocks
+func (s *service) copyThroughDataMover(ctx context.Context, cp *dataMoverCheckpoint, job *dataMoverJob) error {
+	srcDevice, err := s.attachToDataMover(ctx, cp.SourceSystemID, cp.CopySourceID)
+	defer s.detachFromDataMover(cp.SourceSystemID, cp.CopySourceID)
+	if err != nil {
+		return err
+	}
+	dstDevice, err := s.attachToDataMover(ctx, cp.TargetSystemID, cp.TargetVolumeID)
+	defer s.detachFromDataMover(cp.TargetSystemID, cp.TargetVolumeID)
+	if err != nil {
+		return err
+	}
+
+	return copyBlocks(srcDevice, dstDevice, cp, job, func() error {
+		log.Infof("Data mover copied %d of %d bytes to volume %s", cp.CopiedBytes, cp.SizeBytes, cp.TargetName)
+		return s.saveDataMoverCheckpoint(cp)
+	})
+}
+
+// copyBlocks copies cp.SizeBytes from src to dst starting at cp.CopiedBytes, calling checkpoint
+// after each dataMoverCheckpointBytes once dst is synced. Zero chunks are not written so the
+// new thin volume stays thin.
+func copyBlocks(src, dst string, cp *dataMoverCheckpoint, job *dataMoverJob, checkpoint func() error) error {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+	defer in.Close() // #nosec G307
+	out, err := os.OpenFile(filepath.Clean(dst), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer out.Close() // #nosec G307
+
+	buf := make([]byte, dataMoverChunkSize)
+	lastCheckpoint := cp.CopiedBytes
+	for cp.CopiedBytes < cp.SizeBytes {
+		chunk := buf[:min(int64(len(buf)), cp.SizeBytes-cp.CopiedBytes)]
+		if _, err := in.ReadAt(chunk, cp.CopiedBytes); err != nil {
+			return fmt.Errorf("read of %s at %d failed: %w", src, cp.CopiedBytes, err)
+		}
+		if !isZeroChunk(chunk) {
+			if _, err := out.WriteAt(chunk, cp.CopiedBytes); err != nil {
+				return fmt.Errorf("write of %s at %d failed: %w", dst, cp.CopiedBytes, err)
+			}
+		}
+		cp.CopiedBytes += int64(len(chunk))
+		job.copied.Store(cp.CopiedBytes)
+
+		if cp.CopiedBytes-lastCheckpoint >= dataMoverCheckpointBytes || cp.CopiedBytes == cp.SizeBytes {
+			if err := out.Sync(); err != nil {
+				return fmt.Errorf("sync of %s failed: %w", dst, err)
+			}
+			if err := checkpoint(); err != nil {
+				return err
+			}
+			lastCheckpoint = cp.CopiedBytes
+		}
+	}
+	return nil
+}
+
+func isZeroChunk(chunk []byte) bool {
+	for _, b := range chunk {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// createDataMoverSnapshot takes the snapshot of the source volume the copy is made from
+func (s *service) createDataMoverSnapshot(systemID, volID, targetName, genType string) (string, error) {
+	snapName := dataMoverSnapshotPrefix + targetName
+	if len(snapName) > 31 {
+		snapName = snapName[0:31]
+	}
+	// the snapshot may be left by an attempt that stopped before the checkpoint was written
+	if id, err := s.adminClients[systemID].FindVolumeID(snapName); err == nil && id != "" {
+		return id, nil
+	}
+
+	snapshotDefs := []*siotypes.SnapshotDef{{VolumeID: volID, SnapshotName: snapName}}
+	system := s.systems[systemID]
+	var snapResponse *siotypes.SnapshotVolumesResp
+	var err error
+	if genType == "EC" {
+		snapResponse, err = system.CreateThinClone(&siotypes.CreateSnapshotParam{SnapshotDefs: snapshotDefs})
+	} else {
+		snapResponse, err = system.CreateSnapshotConsistencyGroup(&siotypes.SnapshotVolumesParam{SnapshotDefs: snapshotDefs, AccessMode: "ReadOnly"})
+	}
+	if err != nil {
+		return "", fmt.Errorf("snapshot of volume %s failed: %w", volID, err)
+	}
+	if len(snapResponse.VolumeIDList) != 1 {
+		return "", errors.New("expected snapshot ID to be returned but it was not")
+	}
+	return snapResponse.VolumeIDList[0], nil
+}
+
+func (s *service) removeDataMoverSnapshot(systemID, snapID string) error {
+	tgtVol := goscaleio.NewVolume(s.adminClients[systemID])
+	tgtVol.Volume = &siotypes.Volume{ID: snapID}
+	err := tgtVol.RemoveVolume(removeModeOnlyMe)
+	if err != nil && !strings.Contains(err.Error(), sioGatewayVolumeNotFound) {
+		return err
+	}
+	return nil
+}
+
+// attachToDataMover maps the volume to the data mover node through SDC or NVMe/TCP and returns its device
+func (s *service) attachToDataMover(ctx context.Context, systemID, volID string) (string, error) {
+	hostID, hostType, err := s.getHostIDAndType(systemID, s.opts.DataMoverNodeID)
+	if err != nil || hostID == "" {
+		return "", fmt.Errorf("data mover node %s is not a host of system %s: %v", s.opts.DataMoverNodeID, systemID, err)
+	}
+	vol, err := s.getVolByID(volID, systemID)
+	if err != nil {
+		return "", err
+	}
+
+	mapped := false
+	for _, mappedSdcInfo := range vol.MappedSdcInfo {
+		if mappedSdcInfo.SdcID == hostID {
+			mapped = true
+			break
+		}
+	}
+	if !mapped {
+		targetVolume := goscaleio.NewVolume(s.adminClients[systemID])
+		targetVolume.Volume = &siotypes.Volume{ID: volID}
+		if hostType == NVMeTCP {
+			err = targetVolume.MapVolumeNVMe(&siotypes.MapVolumeNVMeParam{HostID: hostID, AllowMultipleMappings: TRUE})
+		} else {
+			err = targetVolume.MapVolumeSdc(&siotypes.MapVolumeSdcParam{SdcID: hostID, AllowMultipleMappings: TRUE})
+		}
+		if err != nil {
+			return "", fmt.Errorf("mapping volume %s to data mover node failed: %w", volID, err)
+		}
+	}
+
+	if hostType == NVMeTCP {
+		return waitForNVMeDevice(ctx, volID, systemID)
+	}
+	sdcMappedVol, err := s.getSDCMappedVol(volID, systemID, dataMoverDeviceRetries)
+	if err != nil {
+		return "", err
+	}
+	return sdcMappedVol.SdcDevice, nil
+}
+
+// detachFromDataMover unmaps the volume from the data mover node
+func (s *service) detachFromDataMover(systemID, volID string) {
+	hostID, hostType, err := s.getHostIDAndType(systemID, s.opts.DataMoverNodeID)
+	if err != nil || hostID == "" {
+		log.Warnf("could not unmap volume %s from data mover node %s: %v", volID, s.opts.DataMoverNodeID, err)
+		return
+	}
+	targetVolume := goscaleio.NewVolume(s.adminClients[systemID])
+	targetVolume.Volume = &siotypes.Volume{ID: volID}
+	if hostType == NVMeTCP {
+		err = targetVolume.RemoveMappedHost(&siotypes.UnmapVolumeNVMeParam{HostID: hostID})
+	} else {
+		err = targetVolume.UnmapVolumeSdc(&siotypes.UnmapVolumeSdcParam{SdcID: hostID})
+	}
+	if err != nil {
+		log.Warnf("could not unmap volume %s from data mover node %s: %s", volID, s.opts.DataMoverNodeID, err.Error())
+	}
+}
+
+// waitForNVMeDevice returns the namespace device of the volume once the host has discovered it
+func waitForNVMeDevice(ctx context.Context, volID, systemID string) (string, error) {
+	nguid, err := buildNGUID(volID, systemID)
+	if err != nil {
+		return "", err
+	}
+	path := nvmeDiskByIDPrefix + nguid
+	for i := 0; i < dataMoverDeviceRetries; i++ {
+		if device, err := filepath.EvalSymlinks(path); err == nil {
+			return device, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(dataMoverDeviceDelay):
+		}
+	}
+	return "", fmt.Errorf("NVMe device %s of volume %s did not appear", path, volID)
+}
+
+func (s *service) dataMoverCheckpointPath(systemID, name string) string {
+	return filepath.Join(s.opts.DataMoverStateDir, systemID+"-"+name+".json")
+}
+
+// loadDataMoverCheckpoint returns the checkpoint of the volume, or nil if it is not being copied
+func (s *service) loadDataMoverCheckpoint(systemID, name string) (*dataMoverCheckpoint, error) {
+	data, err := os.ReadFile(filepath.Clean(s.dataMoverCheckpointPath(systemID, name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := &dataMoverCheckpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// saveDataMoverCheckpoint writes the checkpoint through a rename so a crash never leaves it half written
+func (s *service) saveDataMoverCheckpoint(cp *dataMoverCheckpoint) error {
+	if err := os.MkdirAll(s.opts.DataMoverStateDir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	path := s.dataMoverCheckpointPath(cp.TargetSystemID, cp.TargetName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeDataMoverCheckpoint forgets the copy state of a volume being deleted. Volumes still
+// being copied can not be deleted, the snapshot of an abandoned copy, or of a completed copy that
+// could not be removed then, is removed.
+func (s *service) removeDataMoverCheckpoint(systemID, name string) error {
+	if s.opts.DataMoverNodeID == "" {
+		return nil
+	}
+	key := systemID + "-" + name
+	if value, ok := s.dataMoverJobs.Load(key); ok {
+		select {
+		case <-value.(*dataMoverJob).done:
+			s.dataMoverJobs.Delete(key)
+		default:
+			return status.Errorf(codes.FailedPrecondition, "volume %s is still being copied by the data mover", name)
+		}
+	}
+
+	cp, err := s.loadDataMoverCheckpoint(systemID, name)
+	if err != nil || cp == nil {
+		return nil
+	}
+	if cp.TempSnapshot {
+		if err := s.removeDataMoverSnapshot(cp.SourceSystemID, cp.CopySourceID); err != nil {
+			log.Warnf("could not remove data mover snapshot %s on system %s: %s", cp.CopySourceID, cp.SourceSystemID, err.Error())
+		}
+	}
+	s.deleteDataMoverCheckpoint(systemID, name)
+	return nil
+}
+
+// deleteDataMoverCheckpoint removes the checkpoint file of a volume
+func (s *service) deleteDataMoverCheckpoint(systemID, name string) {
+	if err := os.Remove(s.dataMoverCheckpointPath(systemID, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("could not remove data mover checkpoint of volume %s: %s", name, err.Error())
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCrossSystemSourceID(t *testing.T) {
+	snapSource := &csi.VolumeContentSource{
+		Type: &csi.VolumeContentSource_Snapshot{
+			Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: "sys1-snap1"},
+		},
+	}
+	volSource := &csi.VolumeContentSource{
+		Type: &csi.VolumeContentSource_Volume{
+			Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: "sys1-vol1"},
+		},
+	}
+
+	s := &service{connectedSystemNameToID: map[string]string{}}
+	assert.Equal(t, "", s.getCrossSystemSourceID(snapSource, "sys2"), "data mover not configured")
+
+	s.opts.DataMoverNodeID = "node1"
+	assert.Equal(t, "sys1", s.getCrossSystemSourceID(snapSource, "sys2"))
+	assert.Equal(t, "sys1", s.getCrossSystemSourceID(volSource, "sys2"))
+	assert.Equal(t, "", s.getCrossSystemSourceID(volSource, "sys1"))
+	assert.Equal(t, "", s.getCrossSystemSourceID(nil, "sys2"))
+}
+
+func TestDataMoverCheckpoint(t *testing.T) {
+	s := &service{opts: Opts{DataMoverNodeID: "node1", DataMoverStateDir: filepath.Join(t.TempDir(), "state")}}
+
+	cp, err := s.loadDataMoverCheckpoint("sys2", "vol1")
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+
+	saved := &dataMoverCheckpoint{
+		SourceSystemID: "sys1",
+		SourceID:       "snap1",
+		CopySourceID:   "snap1",
+		TargetSystemID: "sys2",
+		TargetName:     "vol1",
+		TargetVolumeID: "vol2",
+		SizeBytes:      8 * bytesInKiB,
+		CopiedBytes:    4 * bytesInKiB,
+	}
+	assert.NoError(t, s.saveDataMoverCheckpoint(saved))
+
+	cp, err = s.loadDataMoverCheckpoint("sys2", "vol1")
+	assert.NoError(t, err)
+	assert.Equal(t, saved, cp)
+
+	assert.NoError(t, s.removeDataMoverCheckpoint("sys2", "vol1"))
+	cp, err = s.loadDataMoverCheckpoint("sys2", "vol1")
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+
+	// a completed copy is recorded until the checkpoint is deleted
+	saved.Completed = true
+	assert.NoError(t, s.saveDataMoverCheckpoint(saved))
+	cp, err = s.loadDataMoverCheckpoint("sys2", "vol1")
+	assert.NoError(t, err)
+	assert.True(t, cp.Completed)
+	s.deleteDataMoverCheckpoint("sys2", "vol1")
+	cp, err = s.loadDataMoverCheckpoint("sys2", "vol1")
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+
+	// a running copy keeps the volume from being deleted
+	job := &dataMoverJob{done: make(chan struct{})}
+	s.dataMoverJobs.Store("sys2-vol1", job)
+	assert.Error(t, s.removeDataMoverCheckpoint("sys2", "vol1"))
+	close(job.done)
+	assert.NoError(t, s.removeDataMoverCheckpoint("sys2", "vol1"))
+}
+
+func TestCopyBlocks(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+
+	// two chunks of data around a zero chunk, the last chunk is partial
+	size := 3*dataMoverChunkSize + 512
+	data := make([]byte, size)
+	for i := 0; i < dataMoverChunkSize; i++ {
+		data[i] = byte(i)
+		data[2*dataMoverChunkSize+i%512] = 0xff
+	}
+	data[size-1] = 0x01
+	assert.NoError(t, os.WriteFile(src, data, 0o600))
+	assert.NoError(t, os.WriteFile(dst, make([]byte, size), 0o600))
+
+	// the first attempt fails at its first checkpoint
+	cp := &dataMoverCheckpoint{SizeBytes: int64(size)}
+	job := &dataMoverJob{size: int64(size)}
+	checkpoints := 0
+	err := copyBlocks(src, dst, cp, job, func() error {
+		checkpoints++
+		return errors.New("checkpoint failed")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, checkpoints)
+
+	// a resumed copy starts at the checkpointed offset
+	cp.CopiedBytes = dataMoverChunkSize
+	assert.NoError(t, copyBlocks(src, dst, cp, job, func() error { return nil }))
+	assert.Equal(t, int64(size), cp.CopiedBytes)
+	assert.Equal(t, int64(100), job.progress())
+
+	copied, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(data, copied))
+}
+
+func TestIsZeroChunk(t *testing.T) {
+	assert.True(t, isZeroChunk(make([]byte, 16)))
+	assert.False(t, isZeroChunk([]byte{0, 0, 1}))
+}
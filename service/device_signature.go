@@ -0,0 +1,72 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// filesystem gofsutil formats the devices with when the volume has no fsType
+const defaultFormatFsType = "ext4"
+
+// wipeDeviceSignatures erases the signatures of the device. Variable so tests don't need a device.
+var wipeDeviceSignatures = func(ctx context.Context, device string) ([]byte, error) {
+	return exec.CommandContext(ctx, "wipefs", "--all", device).CombinedOutput() // #nosec G204
+}
+
+// signatures of a device that are not filesystems but that mkfs would silently overwrite
+var foreignSignatureTypes = map[string]bool{
+	"LVM2_member": true,
+	"crypto_LUKS": true,
+}
+
+// checkDeviceSignature makes sure a device is blank or already holds the filesystem of the volume
+// before it is formatted. Anything else usually means the device is mapped to the wrong volume or
+// holds data from elsewhere, so it is refused unless the volume allows overwriting it. Then a
+// partition table, LVM physical volume, LUKS header or conflicting superblocks are wiped, which
+// mkfs would do silently, but a filesystem of another type is kept: FormatAndMount never
+// formats a device that holds a filesystem.
+func checkDeviceSignature(ctx context.Context, device, fsType string, allowOverwrite bool) error {
+	if fsType == "" {
+		fsType = defaultFormatFsType
+	}
+	signature, err := getDeviceSignature(ctx, device)
+	if err != nil {
+		return status.Errorf(codes.Internal, "unable to probe the signatures of device %s: %s", device, err.Error())
+	}
+	if signature.isBlank() || signature == (deviceSignature{fsType: fsType}) {
+		return nil
+	}
+	if !allowOverwrite {
+		return status.Errorf(codes.FailedPrecondition,
+			"device %s holds %s instead of a %s filesystem, refusing to use it unless %s is set",
+			device, signature, fsType, KeyAllowFormatOverwrite)
+	}
+	if !signature.ambivalent && signature.ptType == "" && !foreignSignatureTypes[signature.fsType] {
+		log.Warnf("keeping the %s filesystem of device %s instead of formatting it with %s", signature.fsType, device, fsType)
+		return nil
+	}
+
+	log.Warnf("wiping %s from device %s before formatting it with %s", signature, device, fsType)
+	if out, err := wipeDeviceSignatures(ctx, device); err != nil {
+		return status.Errorf(codes.Internal, "unable to wipe the signatures of device %s: %s: %s",
+			device, err.Error(), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
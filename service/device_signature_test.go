@@ -0,0 +1,72 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckDeviceSignature(t *testing.T) {
+	defer func(f func(context.Context, string) (deviceSignature, error)) { getDeviceSignature = f }(getDeviceSignature)
+	defer func(f func(context.Context, string) ([]byte, error)) { wipeDeviceSignatures = f }(wipeDeviceSignatures)
+	ctx := context.Background()
+
+	var wiped []string
+	wipeDeviceSignatures = func(_ context.Context, device string) ([]byte, error) {
+		wiped = append(wiped, device)
+		return nil, nil
+	}
+
+	tests := []struct {
+		name           string
+		signature      deviceSignature
+		fsType         string
+		allowOverwrite bool
+		code           codes.Code
+		wiped          bool
+	}{
+		{name: "blank device", signature: deviceSignature{}, fsType: "xfs", code: codes.OK},
+		{name: "same filesystem", signature: deviceSignature{fsType: "xfs"}, fsType: "xfs", code: codes.OK},
+		{name: "default filesystem", signature: deviceSignature{fsType: "ext4"}, fsType: "", code: codes.OK},
+		{name: "foreign filesystem", signature: deviceSignature{fsType: "xfs"}, fsType: "ext4", code: codes.FailedPrecondition},
+		{name: "partition table", signature: deviceSignature{ptType: "gpt"}, fsType: "ext4", code: codes.FailedPrecondition},
+		{name: "dos partition table", signature: deviceSignature{ptType: "dos"}, fsType: "xfs", code: codes.FailedPrecondition},
+		{name: "LVM physical volume", signature: deviceSignature{fsType: "LVM2_member"}, fsType: "ext4", code: codes.FailedPrecondition},
+		{name: "LUKS header", signature: deviceSignature{fsType: "crypto_LUKS"}, fsType: "ext4", code: codes.FailedPrecondition},
+		{name: "ambivalent signatures", signature: deviceSignature{ambivalent: true}, fsType: "ext4", code: codes.FailedPrecondition},
+		{name: "overwrite allowed", signature: deviceSignature{fsType: "crypto_LUKS"}, fsType: "ext4", allowOverwrite: true, code: codes.OK, wiped: true},
+		{name: "filesystem never wiped", signature: deviceSignature{fsType: "xfs"}, fsType: "ext4", allowOverwrite: true, code: codes.OK},
+		{name: "ambivalent signatures wiped", signature: deviceSignature{ambivalent: true}, fsType: "ext4", allowOverwrite: true, code: codes.OK, wiped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wiped = nil
+			getDeviceSignature = func(_ context.Context, _ string) (deviceSignature, error) { return tt.signature, nil }
+			err := checkDeviceSignature(ctx, "/dev/scinia", tt.fsType, tt.allowOverwrite)
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.wiped, len(wiped) == 1)
+		})
+	}
+
+	getDeviceSignature = func(_ context.Context, _ string) (deviceSignature, error) {
+		return deviceSignature{}, errors.New("blkid failed")
+	}
+	assert.Equal(t, codes.Internal, status.Code(checkDeviceSignature(ctx, "/dev/scinia", "ext4", false)))
+}
@@ -85,6 +85,49 @@ const (
 	// EnvPodmonArrayConnectivityPollRate indicates the polling frequency to check array connectivity
 	EnvPodmonArrayConnectivityPollRate = "X_CSI_PODMON_ARRAY_CONNECTIVITY_POLL_RATE"
 
+	// EnvDataMoverNodeID is the name of the environment variable which stores the CSI node ID of the node the
+	// controller runs on, used to copy volume content sources between systems. The controller needs to be
+	// privileged with access to the /dev of that node, see samples/datamover/controller-patch.yaml.
+	EnvDataMoverNodeID = "X_CSI_POWERFLEX_DATA_MOVER_NODE_ID"
+
+	// EnvDataMoverStateDir is the name of the environment variable which stores the directory where the
+	// progress of copies between systems is kept. Copies resume after a controller restart only when it is
+	// on a volume that outlives the container, otherwise they start over.
+	EnvDataMoverStateDir = "X_CSI_POWERFLEX_DATA_MOVER_STATE_DIR"
+
+	// EnvNVMePathCheckInterval is the name of the environment variable which stores how often the node checks
+	// its NVMe/TCP controllers against the targets of each array and reconnects lost paths, 0 disables the check
+	EnvNVMePathCheckInterval = "X_CSI_POWERFLEX_NVME_PATH_CHECK_INTERVAL"
+
+	// EnvNodeMetricsPort is the name of the environment variable which stores the port the node serves
+	// the NVMe/TCP path counts and the trims of its volumes on, in the Prometheus text format
+	EnvNodeMetricsPort = "X_CSI_POWERFLEX_NODE_METRICS_PORT"
+
+	// EnvNVMeStageRepair is the name of the environment variable which enables the repair of NVMe staging paths
+	// left with a deleted device, a multipath member or an orphaned mount, "false" makes staging fail instead
+	EnvNVMeStageRepair = "X_CSI_POWERFLEX_NVME_STAGE_REPAIR"
+
+	// EnvMountReconcileInterval is the name of the environment variable which stores how often the node looks for
+	// private and staging mounts no pod uses anymore and releases them, the reconciliation is disabled when unset or 0
+	EnvMountReconcileInterval = "X_CSI_POWERFLEX_MOUNT_RECONCILE_INTERVAL"
+
+	// EnvMountReconcileDryRun is the name of the environment variable which makes the mount reconciliation only
+	// log the mounts it would release, "false" makes it release them
+	EnvMountReconcileDryRun = "X_CSI_POWERFLEX_MOUNT_RECONCILE_DRY_RUN"
+
+	// EnvLazyUnmountOnBusy is the name of the environment variable which enables a lazy unmount of the volumes
+	// that can't be unpublished or unstaged because another mount namespace still holds their mounts
+	EnvLazyUnmountOnBusy = "X_CSI_POWERFLEX_LAZY_UNMOUNT_ON_BUSY"
+
+	// EnvFstrimInterval is the name of the environment variable which stores how often the node trims the
+	// filesystem volumes created with periodicTrim, 0 disables the trims
+	EnvFstrimInterval = "X_CSI_POWERFLEX_FSTRIM_INTERVAL"
+
+	// EnvRenameNVMeHosts is the name of the environment variable which lets nodes using SDC for some arrays
+	// and NVMe/TCP for others rename their existing NVMe hosts after their SDC GUID. The node ID then changes
+	// from the NVMe host name to the SDC GUID, so the node must be drained first.
+	EnvRenameNVMeHosts = "X_CSI_POWERFLEX_RENAME_NVME_HOSTS"
+
 	// EnvAuthTyoe is the name of the environment variable which stores the authentication type such as OIDC or Standard Username Password
 	EnvAuthType = "X_CSI_AUTH_TYPE"
 )
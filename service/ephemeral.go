@@ -159,11 +159,8 @@ func (s *service) ephemeralNodePublish(
 		return nil, status.Error(codes.Internal, "inline ephemeral getSystemIDFromCsiVolumeID failed ")
 	}
 
-	NodeID := s.opts.SdcGUID
-	if s.useNVME {
-		log.Infof("SdcGUID is not set, using NodeID: %s", s.nodeID)
-		NodeID = s.nodeID
-	}
+	NodeID := s.getNodeID()
+	log.Infof("using NodeID: %s", NodeID)
 
 	cpubresp, err := s.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
 		NodeId:           NodeID,
@@ -181,7 +178,7 @@ func (s *service) ephemeralNodePublish(
 		})
 		return nil, status.Error(codes.Internal, "inline ephemeral controller publish failed: "+err.Error())
 	}
-	if s.useNVME {
+	if s.isNVMeSystem(systemName) {
 		log.Debug("found NVME ephemeral volume")
 		stageReq := &csi.NodeStageVolumeRequest{
 			StagingTargetPath: filepath.Clean(filepath.Join(ephemeralStagingMountPath, volID)),
@@ -263,14 +260,11 @@ func (s *service) ephemeralNodeUnpublish(
 	}
 
 	goodVolid := string(dat)
-	NodeID := s.opts.SdcGUID
-	if s.useNVME {
-		log.Infof("SdcGUID is not set, using NodeID: %s", s.nodeID)
-		NodeID = s.nodeID
-	}
+	NodeID := s.getNodeID()
+	log.Infof("using NodeID: %s", NodeID)
 	log.Infof("Read volume and array ID from file:%s", goodVolid)
 
-	if s.useNVME {
+	if s.isNVMeSystem(s.getSystemIDFromCsiVolumeID(goodVolid)) {
 		log.Debug("Unstaging NVME ephemeral volume")
 		unStageReq := &csi.NodeUnstageVolumeRequest{
 			StagingTargetPath: stagingPath,
@@ -83,6 +83,16 @@ Feature: VxFlex OS CSI interface
     And I call DeleteVolume nfs with "single-writer"
     Then the error contains "error getting the NFS Export"
 
+  Scenario: Delete volume assigned to a snapshot policy
+    Given a VxFlexOS service
+    When I call Probe
+    And I specify snapshot policy "daily-snapshots"
+    And I call CreateVolume "policy-volume"
+    And volume "policy-volume" is assigned to snapshot policy "daily-snapshots"
+    And I call DeleteVolume "policy-volume"
+    Then a valid DeleteVolumeResponse is returned
+    And volume "policy-volume" is assigned to snapshot policy "none"
+
   Scenario: Delete volume with induced getVolByID error
     Given a VxFlexOS service
     And a valid volume
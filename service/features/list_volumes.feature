@@ -43,6 +43,14 @@ Feature: VxFlex OS CSI interface
     And I call ListVolumes with max_entries "1" and starting_token "none"
     Then the error contains "Unable to list volumes"
 
+  Scenario: Test list volumes with induced filesystem instances error
+    Given a VxFlex OS service
+    And a valid volume
+    And I induce error "FileSystemInstancesError"
+    When I call Probe
+    And I call ListVolumes with max_entries "1" and starting_token "none"
+    Then the error contains "unable to list filesystems"
+
   Scenario: Test list volumes with an starting token greater than volume count
     Given a VxFlex OS service
     And a valid volume
@@ -140,6 +148,15 @@ Feature: VxFlex OS CSI interface
     And I call ListSnapshots for volume "alt"
     And a valid ListSnapshotsResponse is returned with listed "10" and next_token ""
 
+  Scenario: List snapshots taken by a snapshot policy
+    Given a VxFlexOS service
+    And a valid volume
+    And there are 2 valid snapshots of "default" volume
+    And there are 3 snapshots taken by snapshot policy of "default" volume
+    When I call Probe
+    Then I call ListSnapshots for volume "default"
+    And a valid ListSnapshotsResponse is returned with listed "5" and next_token ""
+
   Scenario: List a particular snapshot
     Given a VxFlexOS service
     And a valid volume
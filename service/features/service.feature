@@ -393,6 +393,29 @@ Feature: VxFlex OS CSI interface
       | "SDC"     |
       | "NVMeTCP" |
 
+  Scenario: Create volume assigned to a snapshot policy
+    Given a VxFlexOS service
+    When I call Probe
+    And I specify snapshot policy "daily-snapshots"
+    And I call CreateVolume "policy-volume"
+    Then a valid CreateVolumeResponse is returned
+    And volume "policy-volume" is assigned to snapshot policy "daily-snapshots"
+
+  Scenario: Create volume with an unknown snapshot policy
+    Given a VxFlexOS service
+    When I call Probe
+    And I specify snapshot policy "hourly-snapshots"
+    And I call CreateVolume "policy-volume"
+    Then the error contains "snapshot policy hourly-snapshots not found"
+
+  Scenario: Create NFS volume with a snapshot policy
+    Given a VxFlexOS service
+    When I call Probe
+    And I specify CreateVolumeMountRequest "nfs"
+    And I specify snapshot policy "daily-snapshots"
+    And I call CreateVolume "policy-fs"
+    Then the error contains "snapshotPolicy is not supported for nfs volumes"
+
   Scenario: Create volume with no storage pool
     Given a VxFlexOS service
     When I call Probe
@@ -1818,17 +1841,18 @@ Feature: VxFlex OS CSI interface
       |  "127.0.0.1/255.255.255.255"    | "127.0.0.1/255.255.255.255"   | "external access exists"              |
       |  "127.1.1.0/255.255.255.255"    | "127.0.0.1/255.255.255.255"   | "external access does not exist"      |
 
-  Scenario: Get NAS server id from name
+  Scenario: Get NAS servers from names
     Given a VxFlexOS service
     And I call Probe
-    When I call Get NAS server from name <systemid> <nasservername>
+    When I call Get NAS servers from names <systemid> <nasservernames>
     And I induce error <error>
     Then the error contains <errorMsg>
     Examples:
-      |  systemid                  | nasservername                      |   error               |  errorMsg                                   |
-      |  "15dbbf5617523655"        | "dummy-nas-server"                 |   ""                  |  "none"                                     |
-      |  "15dbbf5617523655"        | "invalid-nas-server-id"            |   "NasNotFoundError"  |  "could not find given NAS server by name"  |
-      |  "15dbbf5617523655"        | ""                                 |   ""                  |  "NAS server not provided"                  |
+      |  systemid                  | nasservernames                           |   error               |  errorMsg                                   |
+      |  "15dbbf5617523655"        | "dummy-nas-server"                       |   ""                  |  "none"                                     |
+      |  "15dbbf5617523655"        | "invalid-nas-server-id,dummy-nas-server" |   ""                  |  "none"                                     |
+      |  "15dbbf5617523655"        | "invalid-nas-server-id"                  |   "NasNotFoundError"  |  "could not find given NAS server by name"  |
+      |  "15dbbf5617523655"        | ""                                       |   ""                  |  "NAS server not provided"                  |
 
   Scenario: Check NFS enabled on Array
     Given a VxFlexOS service
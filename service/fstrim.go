@@ -0,0 +1,289 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/dell/csi-vxflexos/v2/k8sutils"
+	"github.com/dell/csmlog"
+	"github.com/dell/gofsutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultFstrimInterval = 24 * time.Hour
+
+	// how often the scheduler looks for volumes due for a trim, at most one volume of each array
+	// is trimmed each time and the trims of the node run one after the other
+	fstrimCheckPeriod = time.Minute
+
+	// FITRIM ioctl, _IOWR('X', 121, struct fstrim_range)
+	fitrimIoctl = 0xC0185879
+)
+
+// fstrimRange is the struct fstrim_range of the FITRIM ioctl
+type fstrimRange struct {
+	start  uint64
+	length uint64
+	minLen uint64
+}
+
+// fstrimResult is the last trim of a volume
+type fstrimResult struct {
+	attempted time.Time // last trim, successful or not, failed trims wait for the next interval too
+	last      time.Time // last successful trim
+	reclaimed uint64    // bytes reclaimed by all the trims of the volume
+}
+
+// fstrimVolume is a filesystem volume mounted to a pod of the node with periodic trim enabled
+type fstrimVolume struct {
+	volumeID string
+	systemID string
+	path     string
+}
+
+// fitrim discards the unused blocks of the filesystem mounted at path and returns the number of
+// bytes trimmed. Variable so tests don't need a mounted filesystem.
+var fitrim = func(path string) (uint64, error) {
+	dir, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+	r := fstrimRange{length: math.MaxUint64}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), fitrimIoctl, uintptr(unsafe.Pointer(&r))); errno != 0 {
+		return 0, errno
+	}
+	return r.length, nil
+}
+
+// startFstrimScheduler periodically trims the filesystem volumes created with periodicTrim. The
+// filesystems are created without discard, so the blocks freed in them are otherwise never
+// returned to the storage pool of the thin volumes.
+func (s *service) startFstrimScheduler(ctx context.Context) {
+	if s.opts.FstrimInterval <= 0 {
+		log.Info("periodic trim of filesystem volumes is disabled")
+		return
+	}
+	log.Infof("trimming filesystem volumes with %s every %s", KeyPeriodicTrim, s.opts.FstrimInterval)
+
+	ticker := time.NewTicker(fstrimCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.trimVolumes(ctx)
+		}
+	}
+}
+
+// trimVolumes trims the volumes due for a trim, one volume of each array at most
+func (s *service) trimVolumes(ctx context.Context) {
+	volumes, err := s.getFstrimVolumes(ctx)
+	if err != nil {
+		log.Errorf("unable to get the volumes to trim: %s", err.Error())
+		return
+	}
+
+	// forget the volumes no longer mounted on the node
+	mounted := make(map[string]bool)
+	for _, vol := range volumes {
+		mounted[vol.volumeID] = true
+	}
+	s.fstrimResults.Range(func(key, _ interface{}) bool {
+		if !mounted[key.(string)] {
+			s.fstrimResults.Delete(key)
+		}
+		return true
+	})
+
+	for _, vol := range s.selectFstrimVolumes(volumes, time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+		s.trimVolume(vol)
+	}
+}
+
+// getFstrimVolumes returns the filesystem volumes with periodic trim enabled mounted to the pods
+// of the node
+func (s *service) getFstrimVolumes(ctx context.Context) ([]fstrimVolume, error) {
+	mounts, err := gofsutil.GetMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for _, m := range mounts {
+		if pvName := getPVNameFromTargetPath(m.Path); pvName != "" {
+			targets[pvName] = m.Path
+		}
+	}
+	var volumes []fstrimVolume
+	for pvName, path := range targets {
+		vol, err := s.getFstrimPV(ctx, pvName)
+		if err != nil {
+			// not cached, the PersistentVolume is read again at the next cycle
+			log.Warnf("unable to read PersistentVolume %s for periodic trim: %s", pvName, err.Error())
+			continue
+		}
+		if vol != nil {
+			volumes = append(volumes, fstrimVolume{volumeID: vol.volumeID, systemID: vol.systemID, path: path})
+		}
+	}
+
+	// forget the PersistentVolumes no longer mounted on the node
+	s.fstrimPVs.Range(func(key, _ interface{}) bool {
+		if _, ok := targets[key.(string)]; !ok {
+			s.fstrimPVs.Delete(key)
+		}
+		return true
+	})
+	return volumes, nil
+}
+
+// getFstrimPV returns the volume of a PersistentVolume mounted on the node, or nil when it is not a
+// block filesystem volume of the driver with periodic trim enabled. Each PersistentVolume is read
+// once while it is mounted, its driver and volume attributes don't change.
+func (s *service) getFstrimPV(ctx context.Context, pvName string) (*fstrimVolume, error) {
+	if vol, ok := s.fstrimPVs.Load(pvName); ok {
+		return vol.(*fstrimVolume), nil
+	}
+
+	if K8sClientset == nil {
+		if err := k8sutils.CreateKubeClientSet(); err != nil {
+			return nil, err
+		}
+		K8sClientset = k8sutils.Clientset
+	}
+	pv, err := K8sClientset.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	// not found for inline ephemeral volumes, the target path has the name of the pod volume.
+	// NFS volumes are trimmed by the array.
+	var vol *fstrimVolume
+	if err == nil && pv.Spec.CSI != nil && pv.Spec.CSI.Driver == Name && !strings.Contains(pv.Spec.CSI.VolumeHandle, "/") &&
+		strings.EqualFold(pv.Spec.CSI.VolumeAttributes[KeyPeriodicTrim], "true") {
+		vol = &fstrimVolume{
+			volumeID: pv.Spec.CSI.VolumeHandle,
+			systemID: s.getSystemIDFromCsiVolumeID(pv.Spec.CSI.VolumeHandle),
+		}
+	}
+	s.fstrimPVs.Store(pvName, vol)
+	return vol, nil
+}
+
+// getPVNameFromTargetPath returns the PersistentVolume name of a filesystem volume target path,
+// /var/lib/kubelet/pods/<pod UID>/volumes/kubernetes.io~csi/<PV name>/mount
+func getPVNameFromTargetPath(targetPath string) string {
+	if !strings.HasPrefix(targetPath, getTargetPathPrefix()) {
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(targetPath, getTargetPathPrefix()), "/")
+	if len(parts) != 5 || parts[1] != "volumes" || parts[2] != "kubernetes.io~csi" || parts[4] != "mount" {
+		return ""
+	}
+	return parts[3]
+}
+
+// selectFstrimVolumes returns the volumes to trim now: for each array, the volume whose last trim
+// is the oldest, if it is older than the trim interval
+func (s *service) selectFstrimVolumes(volumes []fstrimVolume, now time.Time) []fstrimVolume {
+	lastTrim := func(volumeID string) time.Time {
+		if result, ok := s.fstrimResults.Load(volumeID); ok {
+			return result.(fstrimResult).attempted
+		}
+		return time.Time{}
+	}
+	sort.SliceStable(volumes, func(i, j int) bool {
+		li, lj := lastTrim(volumes[i].volumeID), lastTrim(volumes[j].volumeID)
+		if !li.Equal(lj) {
+			return li.Before(lj)
+		}
+		return volumes[i].volumeID < volumes[j].volumeID
+	})
+
+	var selected []fstrimVolume
+	systems := make(map[string]bool)
+	for _, vol := range volumes {
+		if systems[vol.systemID] || now.Sub(lastTrim(vol.volumeID)) < s.opts.FstrimInterval {
+			continue
+		}
+		systems[vol.systemID] = true
+		selected = append(selected, vol)
+	}
+	return selected
+}
+
+// trimVolume trims the filesystem of a volume and records the result
+func (s *service) trimVolume(vol fstrimVolume) {
+	fields := csmlog.Fields{"volumeID": vol.volumeID, "path": vol.path}
+	start := time.Now()
+	var result fstrimResult
+	if previous, ok := s.fstrimResults.Load(vol.volumeID); ok {
+		result = previous.(fstrimResult)
+	}
+	result.attempted = start
+	defer func() { s.fstrimResults.Store(vol.volumeID, result) }()
+
+	trimmed, err := fitrim(vol.path)
+	if err != nil {
+		log.WithFields(fields).Errorf("unable to trim volume: %s", err.Error())
+		return
+	}
+	log.WithFields(fields).Infof("trimmed %d bytes in %s", trimmed, time.Since(start))
+	result.last = start
+	result.reclaimed += trimmed
+}
+
+// formatFstrimMetrics returns the time of the last trim and the bytes trimmed of each volume in the
+// Prometheus text format
+func (s *service) formatFstrimMetrics() string {
+	results := make(map[string]fstrimResult)
+	ids := make([]string, 0)
+	s.fstrimResults.Range(func(key, value interface{}) bool {
+		results[key.(string)] = value.(fstrimResult)
+		ids = append(ids, key.(string))
+		return true
+	})
+	sort.Strings(ids)
+
+	var b strings.Builder
+	b.WriteString("# HELP powerflex_fstrim_last_time_seconds Time of the last trim of the volume filesystem\n")
+	b.WriteString("# TYPE powerflex_fstrim_last_time_seconds gauge\n")
+	for _, id := range ids {
+		if !results[id].last.IsZero() {
+			fmt.Fprintf(&b, "powerflex_fstrim_last_time_seconds{volume_id=%q} %d\n", id, results[id].last.Unix())
+		}
+	}
+	b.WriteString("# HELP powerflex_fstrim_reclaimed_bytes_total Bytes trimmed from the volume filesystem\n")
+	b.WriteString("# TYPE powerflex_fstrim_reclaimed_bytes_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "powerflex_fstrim_reclaimed_bytes_total{volume_id=%q} %d\n", id, results[id].reclaimed)
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dell/gofsutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGetPVNameFromTargetPath(t *testing.T) {
+	podDir := getTargetPathPrefix() + "0d4c6d6e-3c0a-4a43-9a4b-6d7cfa2c0b11/"
+	assert.Equal(t, "pvc-1", getPVNameFromTargetPath(podDir+"volumes/kubernetes.io~csi/pvc-1/mount"))
+	assert.Equal(t, "", getPVNameFromTargetPath(podDir+"volumeDevices/kubernetes.io~csi/pvc-1"))
+	assert.Equal(t, "", getPVNameFromTargetPath(podDir+"volumes/kubernetes.io~csi/pvc-1/mount/data"))
+	assert.Equal(t, "", getPVNameFromTargetPath("/mnt/volumes/kubernetes.io~csi/pvc-1/mount"))
+}
+
+func TestGetFstrimVolumes(t *testing.T) {
+	gofsutil.UseMockFS()
+	defer func() { gofsutil.GOFSMockMounts = gofsutil.GOFSMockMounts[:0] }()
+	defer func() { K8sClientset = nil }()
+
+	pv := func(name, handle, periodicTrim string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           Name,
+					VolumeHandle:     handle,
+					VolumeAttributes: map[string]string{KeyPeriodicTrim: periodicTrim},
+				},
+			}},
+		}
+	}
+	K8sClientset = fake.NewSimpleClientset(
+		pv("pvc-1", "sys1-vol1", "true"),
+		pv("pvc-2", "sys1-vol2", "false"),
+		pv("pvc-3", "sys1/fs3", "true"),
+		// not mounted on the node
+		pv("pvc-4", "sys1-vol4", "true"),
+	)
+	podDir := getTargetPathPrefix() + "0d4c6d6e-3c0a-4a43-9a4b-6d7cfa2c0b11/volumes/kubernetes.io~csi/"
+	gofsutil.GOFSMockMounts = []gofsutil.Info{
+		{Device: "/dev/scinia", Path: podDir + "pvc-1/mount"},
+		{Device: "/dev/scinib", Path: podDir + "pvc-2/mount"},
+		{Device: "nfs:/fs3", Path: podDir + "pvc-3/mount"},
+		// inline ephemeral volume
+		{Device: "/dev/scinic", Path: podDir + "scratch/mount"},
+	}
+
+	s := &service{}
+	volumes, err := s.getFstrimVolumes(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []fstrimVolume{{volumeID: "sys1-vol1", systemID: "sys1", path: podDir + "pvc-1/mount"}}, volumes)
+
+	// only the mounted PersistentVolumes are read, each once
+	actions := K8sClientset.(*fake.Clientset).Actions()
+	assert.Len(t, actions, 4)
+	for _, action := range actions {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+	K8sClientset.(*fake.Clientset).ClearActions()
+	volumes, err = s.getFstrimVolumes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, volumes, 1)
+	assert.Empty(t, K8sClientset.(*fake.Clientset).Actions())
+
+	// a PersistentVolume that can't be read is skipped, the others are still trimmed
+	K8sClientset.(*fake.Clientset).PrependReactor("get", "persistentvolumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.GetAction).GetName() == "pvc-5" {
+			return true, nil, errors.New("connection refused")
+		}
+		return false, nil, nil
+	})
+	gofsutil.GOFSMockMounts = append(gofsutil.GOFSMockMounts, gofsutil.Info{Device: "/dev/scinid", Path: podDir + "pvc-5/mount"})
+	volumes, err = s.getFstrimVolumes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, volumes, 1)
+	_, cached := s.fstrimPVs.Load("pvc-5")
+	assert.False(t, cached)
+
+	// unmounted volumes are forgotten
+	gofsutil.GOFSMockMounts = gofsutil.GOFSMockMounts[:0]
+	volumes, err = s.getFstrimVolumes(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, volumes)
+	_, cached = s.fstrimPVs.Load("pvc-1")
+	assert.False(t, cached)
+}
+
+func TestSelectFstrimVolumes(t *testing.T) {
+	now := time.Now()
+	s := &service{opts: Opts{FstrimInterval: time.Hour}}
+	s.fstrimResults.Store("sys1-vol1", fstrimResult{attempted: now.Add(-2 * time.Hour)})
+	s.fstrimResults.Store("sys1-vol2", fstrimResult{attempted: now.Add(-3 * time.Hour)})
+	s.fstrimResults.Store("sys2-vol3", fstrimResult{attempted: now.Add(-time.Minute)})
+
+	volumes := []fstrimVolume{
+		{volumeID: "sys1-vol1", systemID: "sys1"},
+		{volumeID: "sys1-vol2", systemID: "sys1"},
+		{volumeID: "sys2-vol3", systemID: "sys2"},
+		{volumeID: "sys2-vol4", systemID: "sys2"},
+		{volumeID: "sys3-vol5", systemID: "sys3"},
+	}
+	selected := s.selectFstrimVolumes(volumes, now)
+	assert.Equal(t, []fstrimVolume{
+		{volumeID: "sys2-vol4", systemID: "sys2"},
+		{volumeID: "sys3-vol5", systemID: "sys3"},
+		{volumeID: "sys1-vol2", systemID: "sys1"},
+	}, selected)
+}
+
+func TestTrimVolume(t *testing.T) {
+	defer func(f func(string) (uint64, error)) { fitrim = f }(fitrim)
+	s := &service{}
+
+	fitrim = func(_ string) (uint64, error) { return 4096, nil }
+	s.trimVolume(fstrimVolume{volumeID: "sys1-vol1", systemID: "sys1", path: "/mnt"})
+	s.trimVolume(fstrimVolume{volumeID: "sys1-vol1", systemID: "sys1", path: "/mnt"})
+
+	fitrim = func(_ string) (uint64, error) { return 0, errors.New("operation not supported") }
+	s.trimVolume(fstrimVolume{volumeID: "sys1-vol2", systemID: "sys1", path: "/mnt"})
+
+	result, ok := s.fstrimResults.Load("sys1-vol2")
+	assert.True(t, ok)
+	assert.False(t, result.(fstrimResult).attempted.IsZero())
+	assert.True(t, result.(fstrimResult).last.IsZero())
+
+	metrics := s.formatFstrimMetrics()
+	assert.Contains(t, metrics, `powerflex_fstrim_reclaimed_bytes_total{volume_id="sys1-vol1"} 8192`)
+	assert.Contains(t, metrics, `powerflex_fstrim_reclaimed_bytes_total{volume_id="sys1-vol2"} 0`)
+	assert.Contains(t, metrics, `powerflex_fstrim_last_time_seconds{volume_id="sys1-vol1"} `)
+	assert.NotContains(t, metrics, `powerflex_fstrim_last_time_seconds{volume_id="sys1-vol2"}`)
+}
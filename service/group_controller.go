@@ -0,0 +1,261 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	volumeGroupSnapshot "github.com/dell/dell-csi-extensions/volumeGroupSnapshot"
+	"github.com/dell/goscaleio"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// groupControllerService implements the CSI GroupController service used by the upstream
+// VolumeGroupSnapshot API. It is a separate type as service already implements
+// CreateVolumeGroupSnapshot for the Dell volumeGroupSnapshot extension.
+// Group snapshot IDs are <systemID>-<snapshot consistency group ID>.
+type groupControllerService struct {
+	csi.UnimplementedGroupControllerServer
+	s *service
+}
+
+// GroupControllerGetCapabilities returns the group controller capabilities
+func (g *groupControllerService) GroupControllerGetCapabilities(
+	_ context.Context,
+	_ *csi.GroupControllerGetCapabilitiesRequest) (
+	*csi.GroupControllerGetCapabilitiesResponse, error,
+) {
+	return &csi.GroupControllerGetCapabilitiesResponse{
+		Capabilities: []*csi.GroupControllerServiceCapability{
+			{
+				Type: &csi.GroupControllerServiceCapability_Rpc{
+					Rpc: &csi.GroupControllerServiceCapability_RPC{
+						Type: csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// CreateVolumeGroupSnapshot creates a snapshot consistency group of the source volumes
+func (g *groupControllerService) CreateVolumeGroupSnapshot(
+	ctx context.Context,
+	req *csi.CreateVolumeGroupSnapshotRequest) (
+	*csi.CreateVolumeGroupSnapshotResponse, error,
+) {
+	log.Infof("CreateVolumeGroupSnapshot (GroupController) called with req: %v", req)
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "group snapshot name cannot be empty")
+	}
+	for _, id := range req.GetSourceVolumeIds() {
+		if strings.Contains(id, "/") {
+			return nil, status.Errorf(codes.InvalidArgument, "group snapshots of NFS volumes are not supported: %s", id)
+		}
+	}
+
+	// the same consistency group snapshot and idempotency logic as the volumeGroupSnapshot extension is used
+	vgsResp, err := g.s.CreateVolumeGroupSnapshot(ctx, &volumeGroupSnapshot.CreateVolumeGroupSnapshotRequest{
+		Name:            shortenGroupSnapshotName(req.GetName()),
+		SourceVolumeIDs: req.GetSourceVolumeIds(),
+		Parameters:      req.GetParameters(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	systemID, cgID, err := g.s.parseGroupSnapshotID(ctx, vgsResp.SnapshotGroupID)
+	if err != nil {
+		return nil, err
+	}
+	groupSnapshot, err := g.s.getVolumeGroupSnapshot(systemID, cgID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &csi.CreateVolumeGroupSnapshotResponse{GroupSnapshot: groupSnapshot}, nil
+}
+
+// DeleteVolumeGroupSnapshot removes all the snapshots of the consistency group
+func (g *groupControllerService) DeleteVolumeGroupSnapshot(
+	ctx context.Context,
+	req *csi.DeleteVolumeGroupSnapshotRequest) (
+	*csi.DeleteVolumeGroupSnapshotResponse, error,
+) {
+	log.Infof("DeleteVolumeGroupSnapshot called with req: %v", req)
+
+	systemID, cgID, err := g.s.parseGroupSnapshotID(ctx, req.GetGroupSnapshotId())
+	if err != nil {
+		return nil, err
+	}
+	adminClient := g.s.adminClients[systemID]
+
+	snaps, err := getConsistencyGroupSnapshots(adminClient, cgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(snaps) == 0 {
+		log.Infof("Group snapshot %s already deleted", req.GetGroupSnapshotId())
+		return &csi.DeleteVolumeGroupSnapshotResponse{}, nil
+	}
+	if err := checkGroupSnapshotIDs(systemID, snaps, req.GetSnapshotIds()); err != nil {
+		return nil, err
+	}
+
+	exposedVols := make([]string, 0)
+	retainedVols := make([]string, 0)
+	for _, snap := range snaps {
+		if len(snap.MappedSdcInfo) > 0 {
+			exposedVols = append(exposedVols, fmt.Sprintf("%s (%s) ", snap.Name, snap.ID))
+		}
+		if expiry, retained := secureSnapshotExpiry(snap); retained {
+			retainedVols = append(retainedVols, fmt.Sprintf("%s (%s) until %s ", snap.Name, snap.ID, expiry.UTC().Format(time.RFC3339)))
+		}
+	}
+	if len(exposedVols) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "One or more consistency group volumes are exposed and may be in use: %v", exposedVols)
+	}
+	if len(retainedVols) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "One or more consistency group volumes are secure snapshots still retained: %v", retainedVols)
+	}
+
+	g.s.clearCache()
+	for _, snap := range snaps {
+		tgtVol := goscaleio.NewVolume(adminClient)
+		tgtVol.Volume = snap
+		err = tgtVol.RemoveVolume(removeModeOnlyMe)
+		if err != nil && !strings.Contains(err.Error(), sioGatewayVolumeNotFound) {
+			return nil, status.Errorf(codes.Internal, "error removing snapshot: %s", err.Error())
+		}
+	}
+	return &csi.DeleteVolumeGroupSnapshotResponse{}, nil
+}
+
+// GetVolumeGroupSnapshot returns the snapshots of the consistency group
+func (g *groupControllerService) GetVolumeGroupSnapshot(
+	ctx context.Context,
+	req *csi.GetVolumeGroupSnapshotRequest) (
+	*csi.GetVolumeGroupSnapshotResponse, error,
+) {
+	log.Infof("GetVolumeGroupSnapshot called with req: %v", req)
+
+	systemID, cgID, err := g.s.parseGroupSnapshotID(ctx, req.GetGroupSnapshotId())
+	if err != nil {
+		return nil, err
+	}
+	groupSnapshot, err := g.s.getVolumeGroupSnapshot(systemID, cgID, req.GetSnapshotIds())
+	if err != nil {
+		return nil, err
+	}
+	return &csi.GetVolumeGroupSnapshotResponse{GroupSnapshot: groupSnapshot}, nil
+}
+
+// parseGroupSnapshotID returns the system and consistency group ID of a group snapshot ID, probing the system
+func (s *service) parseGroupSnapshotID(ctx context.Context, groupSnapshotID string) (string, string, error) {
+	if groupSnapshotID == "" {
+		return "", "", status.Error(codes.InvalidArgument, "group snapshot ID is required")
+	}
+	systemID := s.getSystemIDFromCsiVolumeID(groupSnapshotID)
+	if systemID == "" {
+		systemID = s.opts.defaultSystemID
+	}
+	if systemID == "" {
+		return "", "", status.Error(codes.InvalidArgument,
+			"systemID is not found in the group snapshot ID and there is no default system")
+	}
+	if err := s.requireProbe(ctx, systemID); err != nil {
+		return "", "", err
+	}
+	return systemID, getVolumeIDFromCsiVolumeID(groupSnapshotID), nil
+}
+
+// getVolumeGroupSnapshot builds the CSI group snapshot from the snapshots in the consistency group
+func (s *service) getVolumeGroupSnapshot(systemID string, cgID string, snapshotIDs []string) (*csi.VolumeGroupSnapshot, error) {
+	groupSnapshotID := systemID + "-" + cgID
+	snaps, err := getConsistencyGroupSnapshots(s.adminClients[systemID], cgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(snaps) == 0 {
+		return nil, status.Errorf(codes.NotFound, "group snapshot %s not found", groupSnapshotID)
+	}
+	if err := checkGroupSnapshotIDs(systemID, snaps, snapshotIDs); err != nil {
+		return nil, err
+	}
+
+	groupSnapshot := &csi.VolumeGroupSnapshot{
+		GroupSnapshotId: groupSnapshotID,
+		ReadyToUse:      true,
+	}
+	for _, snap := range snaps {
+		csiSnap := s.getCSISnapshot(snap, systemID)
+		groupSnapshot.Snapshots = append(groupSnapshot.Snapshots, csiSnap)
+		// all snapshots of the group share the creation time, keep the earliest just in case
+		if groupSnapshot.CreationTime == nil || csiSnap.CreationTime.AsTime().Before(groupSnapshot.CreationTime.AsTime()) {
+			groupSnapshot.CreationTime = csiSnap.CreationTime
+		}
+	}
+	return groupSnapshot, nil
+}
+
+// getConsistencyGroupSnapshots returns the snapshots belonging to the snapshot consistency group
+func getConsistencyGroupSnapshots(adminClient *goscaleio.Client, cgID string) ([]*siotypes.Volume, error) {
+	if cgID == "" {
+		return nil, status.Error(codes.InvalidArgument, "consistency group ID is required")
+	}
+	sioVols, err := adminClient.GetVolume("", "", "", "", true)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failure listing snapshots: %s", err.Error())
+	}
+	snaps := make([]*siotypes.Volume, 0)
+	for _, vol := range sioVols {
+		if vol.ConsistencyGroupID == cgID {
+			snaps = append(snaps, vol)
+		}
+	}
+	return snaps, nil
+}
+
+// checkGroupSnapshotIDs verifies that every given CSI snapshot ID is part of the group
+func checkGroupSnapshotIDs(systemID string, snaps []*siotypes.Volume, snapshotIDs []string) error {
+	inGroup := make(map[string]bool, len(snaps))
+	for _, snap := range snaps {
+		inGroup[systemID+"-"+snap.ID] = true
+	}
+	for _, id := range snapshotIDs {
+		if !inGroup[id] {
+			return status.Errorf(codes.FailedPrecondition, "snapshot %s is not part of the group snapshot", id)
+		}
+	}
+	return nil
+}
+
+// shortenGroupSnapshotName fits the group snapshot name into the 27 characters allowed by
+// validateCreateVGSreq, the same way CreateSnapshot shortens snapshot names
+func shortenGroupSnapshotName(name string) string {
+	if len(name) <= 27 {
+		return name
+	}
+	shortName := strings.Replace(name, "groupsnapshot-", "gs-", 1)
+	shortName = strings.TrimRight(shortName[0:int(math.Min(float64(len(shortName)), 27))], "-")
+	log.Infof("Requested group snapshot name %s longer than 27 character max, truncated to %s", name, shortName)
+	return shortName
+}
@@ -0,0 +1,51 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGroupControllerGetCapabilities(t *testing.T) {
+	g := &groupControllerService{s: &service{}}
+	resp, err := g.GroupControllerGetCapabilities(context.Background(), &csi.GroupControllerGetCapabilitiesRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetCapabilities(), 1)
+	assert.Equal(t, csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT,
+		resp.GetCapabilities()[0].GetRpc().GetType())
+}
+
+func TestShortenGroupSnapshotName(t *testing.T) {
+	assert.Equal(t, "my-group", shortenGroupSnapshotName("my-group"))
+	assert.Equal(t, "gs-5b4a4c1e-2f3d-4a1b-9c8d",
+		shortenGroupSnapshotName("groupsnapshot-5b4a4c1e-2f3d-4a1b-9c8d-7e6f5a4b3c2d"))
+	assert.Len(t, shortenGroupSnapshotName("a-very-long-group-snapshot-name-without-prefix"), 27)
+}
+
+func TestCheckGroupSnapshotIDs(t *testing.T) {
+	snaps := []*siotypes.Volume{{ID: "snap1"}, {ID: "snap2"}}
+
+	assert.NoError(t, checkGroupSnapshotIDs("sys1", snaps, nil))
+	assert.NoError(t, checkGroupSnapshotIDs("sys1", snaps, []string{"sys1-snap1", "sys1-snap2"}))
+
+	err := checkGroupSnapshotIDs("sys1", snaps, []string{"sys1-snap1", "sys1-snap3"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
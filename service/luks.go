@@ -0,0 +1,298 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/dell/gofsutil"
+	"github.com/dell/goscaleio"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// EncryptionLUKS is the value of the encryption parameter for volumes encrypted by the node with dm-crypt/LUKS
+	EncryptionLUKS = "luks"
+
+	// KeyLUKSPassphrase is the key of the passphrase in the node stage secret of LUKS encrypted volumes
+	KeyLUKSPassphrase = "luksPassphrase"
+
+	// prefix of the device mapper names of the LUKS encrypted volumes
+	luksMapperPrefix = "luks-"
+
+	// exit status of blkid when no signature is found on the device
+	blkidNoSignature = 2
+	// exit status of blkid when the device holds conflicting signatures
+	blkidAmbivalent = 8
+)
+
+// luksMapperDir is where the opened LUKS devices are, variable so tests can use a temporary directory
+var luksMapperDir = "/dev/mapper"
+
+// cryptsetup runs cryptsetup, passing the key on the standard input. Variable so tests don't need
+// dm-crypt.
+var cryptsetup = func(ctx context.Context, key []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "cryptsetup", args...) // #nosec G204
+	if key != nil {
+		cmd.Stdin = bytes.NewReader(key)
+	}
+	return cmd.CombinedOutput()
+}
+
+// deviceSignature is what blkid finds on a device: the type of its filesystem, LVM physical volume,
+// LUKS header or other superblock, and the type of its partition table
+type deviceSignature struct {
+	fsType     string
+	ptType     string
+	ambivalent bool // conflicting superblocks, blkid reports none of them
+}
+
+// isBlank returns true when no signature was found on the device
+func (d deviceSignature) isBlank() bool {
+	return d == (deviceSignature{})
+}
+
+func (d deviceSignature) String() string {
+	if d.ambivalent {
+		return "ambivalent signatures"
+	}
+	var types []string
+	if d.fsType != "" {
+		types = append(types, d.fsType)
+	}
+	if d.ptType != "" {
+		types = append(types, d.ptType+" partition table")
+	}
+	return strings.Join(types, ", ")
+}
+
+// getDeviceSignature returns the signature found on the device. Variable so tests don't need a device.
+var getDeviceSignature = func(ctx context.Context, device string) (deviceSignature, error) {
+	out, err := exec.CommandContext(ctx, "blkid", "-p", "-s", "TYPE", "-s", "PTTYPE", "-o", "export", device).CombinedOutput() // #nosec G204
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == blkidNoSignature {
+		return deviceSignature{}, nil
+	}
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == blkidAmbivalent {
+		return deviceSignature{ambivalent: true}, nil
+	}
+	if err != nil {
+		return deviceSignature{}, fmt.Errorf("blkid %s: %s: %s", device, err.Error(), strings.TrimSpace(string(out)))
+	}
+	var signature deviceSignature
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+		switch key {
+		case "TYPE":
+			signature.fsType = value
+		case "PTTYPE":
+			signature.ptType = value
+		}
+	}
+	return signature, nil
+}
+
+// isLUKSEncrypted returns true when the volume was created with LUKS encryption
+func isLUKSEncrypted(volumeContext map[string]string) bool {
+	return strings.EqualFold(volumeContext[KeyEncryption], EncryptionLUKS)
+}
+
+// luksPassphrase returns the passphrase of an encrypted volume from the node stage secret
+func luksPassphrase(secrets map[string]string) ([]byte, error) {
+	passphrase := secrets[KeyLUKSPassphrase]
+	if passphrase == "" {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"node stage secret must contain %s for %s encrypted volumes", KeyLUKSPassphrase, EncryptionLUKS)
+	}
+	return []byte(passphrase), nil
+}
+
+// luksMapperName returns the device mapper name of the opened LUKS device of a CSI volume
+func luksMapperName(csiVolID string) string {
+	return luksMapperPrefix + strings.ReplaceAll(csiVolID, "/", "-")
+}
+
+// luksDevicePath returns the path of the opened LUKS device of a CSI volume, or "" when it is not open
+func luksDevicePath(csiVolID string) string {
+	path := filepath.Join(luksMapperDir, luksMapperName(csiVolID))
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// luksOpen opens the LUKS device of a volume on top of its SDC or NVMe device and returns the
+// path of the decrypted device. A blank device is formatted with LUKS first; a device holding
+// any other signature is refused, it was not created as an encrypted volume.
+func luksOpen(ctx context.Context, csiVolID, device string, passphrase []byte) (string, error) {
+	if path := luksDevicePath(csiVolID); path != "" {
+		log.Infof("LUKS device %s of volume %s is already open", path, csiVolID)
+		return path, nil
+	}
+
+	if _, err := cryptsetup(ctx, nil, "isLuks", device); err != nil {
+		signature, err := getDeviceSignature(ctx, device)
+		if err != nil {
+			return "", status.Errorf(codes.Internal, "unable to probe device %s of volume %s: %s", device, csiVolID, err.Error())
+		}
+		if !signature.isBlank() {
+			return "", status.Errorf(codes.FailedPrecondition,
+				"device %s of volume %s holds %s, not a LUKS header: refusing to encrypt it", device, csiVolID, signature)
+		}
+		log.Infof("formatting device %s of volume %s with LUKS", device, csiVolID)
+		if out, err := cryptsetup(ctx, passphrase, "luksFormat", "--type", "luks2", "--batch-mode", "--key-file", "-", device); err != nil {
+			return "", status.Errorf(codes.Internal, "unable to format device %s of volume %s with LUKS: %s: %s",
+				device, csiVolID, err.Error(), strings.TrimSpace(string(out)))
+		}
+	}
+
+	// discards are allowed so trims of the filesystem reach the thin volume
+	name := luksMapperName(csiVolID)
+	if out, err := cryptsetup(ctx, passphrase, "open", "--type", "luks", "--allow-discards", "--key-file", "-", device, name); err != nil {
+		return "", status.Errorf(codes.Internal, "unable to open LUKS device %s of volume %s: %s: %s",
+			device, csiVolID, err.Error(), strings.TrimSpace(string(out)))
+	}
+	log.Infof("opened LUKS device %s of volume %s as %s", device, csiVolID, name)
+	return filepath.Join(luksMapperDir, name), nil
+}
+
+// luksBackingDevice returns the SDC or NVMe device the opened LUKS device of a volume is on
+func luksBackingDevice(ctx context.Context, csiVolID string) (string, error) {
+	out, err := cryptsetup(ctx, nil, "status", luksMapperName(csiVolID))
+	if err != nil {
+		return "", fmt.Errorf("cryptsetup status: %s: %s", err.Error(), strings.TrimSpace(string(out)))
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if device, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "device:"); ok {
+			return strings.TrimSpace(device), nil
+		}
+	}
+	return "", fmt.Errorf("no device in the status of %s", luksMapperName(csiVolID))
+}
+
+// luksClose closes the LUKS device of a volume, if it is open
+func luksClose(ctx context.Context, csiVolID string) error {
+	if luksDevicePath(csiVolID) == "" {
+		return nil
+	}
+	if out, err := cryptsetup(ctx, nil, "close", luksMapperName(csiVolID)); err != nil {
+		return fmt.Errorf("unable to close LUKS device of volume %s: %s: %s", csiVolID, err.Error(), strings.TrimSpace(string(out)))
+	}
+	log.Infof("closed LUKS device of volume %s", csiVolID)
+	return nil
+}
+
+// luksResize grows the opened LUKS device of a volume to the size of its expanded device. The
+// passphrase is only needed when the volume key is not in the kernel keyring.
+func luksResize(ctx context.Context, csiVolID string, passphrase []byte) error {
+	args := []string{"resize", luksMapperName(csiVolID)}
+	if passphrase != nil {
+		args = append(args, "--key-file", "-")
+	}
+	if out, err := cryptsetup(ctx, passphrase, args...); err != nil {
+		return status.Errorf(codes.Internal, "unable to resize LUKS device of volume %s: %s: %s",
+			csiVolID, err.Error(), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// stageLUKSVolume opens the LUKS device of an encrypted SDC volume, the only staging SDC volumes need
+func (s *service) stageLUKSVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	csiVolID := req.GetVolumeId()
+	if csiVolID == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume ID is required")
+	}
+	passphrase, err := luksPassphrase(req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
+	systemID := s.getSystemIDFromCsiVolumeID(csiVolID)
+	if systemID == "" {
+		systemID = s.opts.defaultSystemID
+	}
+	if systemID == "" {
+		return nil, status.Error(codes.InvalidArgument, "systemID is not found in the request and there is no default system")
+	}
+	if err := s.requireProbe(ctx, systemID); err != nil {
+		return nil, err
+	}
+
+	sdcMappedVol, err := s.getSDCMappedVol(getVolumeIDFromCsiVolumeID(csiVolID), systemID, publishGetMappedVolMaxRetry)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := luksOpen(ctx, csiVolID, sdcMappedVol.SdcDevice, passphrase); err != nil {
+		return nil, err
+	}
+	return &csi.NodeStageVolumeResponse{}, nil
+}
+
+// expandLUKSVolume rescans the SDC or NVMe device of an encrypted volume, then grows the LUKS
+// device on top of it and the filesystem of the volume
+func (s *service) expandLUKSVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest, systemID string) (*csi.NodeExpandVolumeResponse, error) {
+	csiVolID := req.GetVolumeId()
+	device, err := luksBackingDevice(ctx, csiVolID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if s.isNVMeSystem(systemID) {
+		controller, err := gofsutil.GetNVMeController(strings.TrimPrefix(device, "/dev/"))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "unable to find the NVMe controller of device %s: %s", device, err.Error())
+		}
+		if controller != "" {
+			if err := s.nvmeLib.DeviceRescan("/dev/" + controller); err != nil {
+				return nil, status.Errorf(codes.Internal, "unable to rescan device %s: %s", device, err.Error())
+			}
+		}
+	} else if rc, err := goscaleio.DrvCfgQueryRescan(); err != nil {
+		log.Errorf("Rescan failed with ioctl error code %s with error %s, Run rescan manually on Powerflex host", rc, err.Error())
+	}
+
+	var passphrase []byte
+	if secret := req.GetSecrets()[KeyLUKSPassphrase]; secret != "" {
+		passphrase = []byte(secret)
+	}
+	if err := luksResize(ctx, csiVolID, passphrase); err != nil {
+		return nil, err
+	}
+	log.Infof("resized LUKS device of volume %s on device %s", csiVolID, device)
+
+	volumePath := req.GetVolumePath()
+	if info, err := os.Stat(volumePath); err != nil || !info.IsDir() {
+		// raw block volume, the application sees the new size of the LUKS device
+		return &csi.NodeExpandVolumeResponse{}, nil
+	}
+	fsType, err := gofsutil.FindFSType(ctx, volumePath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to find the filesystem type of %s: %s", volumePath, err.Error())
+	}
+	if err := gofsutil.ResizeFS(ctx, volumePath, luksDevicePath(csiVolID), "", "", fsType); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to resize filesystem of volume %s: %s", csiVolID, err.Error())
+	}
+	return &csi.NodeExpandVolumeResponse{}, nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeCryptsetup records the cryptsetup commands and creates the mapper device on open
+func fakeCryptsetup(t *testing.T, isLuks bool) *[]string {
+	calls := &[]string{}
+	cryptsetup = func(_ context.Context, _ []byte, args ...string) ([]byte, error) {
+		*calls = append(*calls, args[0])
+		switch args[0] {
+		case "isLuks":
+			if !isLuks {
+				return nil, errors.New("exit status 1")
+			}
+		case "open":
+			assert.NoError(t, os.WriteFile(filepath.Join(luksMapperDir, args[len(args)-1]), nil, 0o600))
+		case "status":
+			return []byte("/dev/mapper/" + args[1] + " is active.\n  type:    LUKS2\n  device:  /dev/scinia\n"), nil
+		}
+		return nil, nil
+	}
+	return calls
+}
+
+func TestLUKSOpen(t *testing.T) {
+	defer func(dir string) { luksMapperDir = dir }(luksMapperDir)
+	defer func(f func(context.Context, []byte, ...string) ([]byte, error)) { cryptsetup = f }(cryptsetup)
+	defer func(f func(context.Context, string) (deviceSignature, error)) { getDeviceSignature = f }(getDeviceSignature)
+	ctx := context.Background()
+	passphrase := []byte("secret")
+
+	// a blank device is formatted on first use
+	luksMapperDir = t.TempDir()
+	calls := fakeCryptsetup(t, false)
+	getDeviceSignature = func(_ context.Context, _ string) (deviceSignature, error) { return deviceSignature{}, nil }
+	path, err := luksOpen(ctx, "sys1-vol1", "/dev/scinia", passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(luksMapperDir, "luks-sys1-vol1"), path)
+	assert.Equal(t, []string{"isLuks", "luksFormat", "open"}, *calls)
+
+	// an open device is reused
+	*calls = nil
+	path, err = luksOpen(ctx, "sys1-vol1", "/dev/scinia", passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, luksDevicePath("sys1-vol1"), path)
+	assert.Empty(t, *calls)
+
+	// a device with a LUKS header is only opened
+	calls = fakeCryptsetup(t, true)
+	_, err = luksOpen(ctx, "sys1-vol2", "/dev/scinib", passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"isLuks", "open"}, *calls)
+
+	// a device holding a filesystem is not encrypted
+	calls = fakeCryptsetup(t, false)
+	getDeviceSignature = func(_ context.Context, _ string) (deviceSignature, error) {
+		return deviceSignature{fsType: "ext4"}, nil
+	}
+	_, err = luksOpen(ctx, "sys1-vol3", "/dev/scinic", passphrase)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, []string{"isLuks"}, *calls)
+
+	device, err := luksBackingDevice(ctx, "sys1-vol1")
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/scinia", device)
+
+	*calls = nil
+	assert.NoError(t, luksClose(ctx, "sys1-vol1"))
+	assert.NoError(t, luksClose(ctx, "sys1-vol3"))
+	assert.Equal(t, []string{"close"}, *calls)
+}
+
+func TestLUKSVolumeContext(t *testing.T) {
+	assert.True(t, isLUKSEncrypted(map[string]string{KeyEncryption: "LUKS"}))
+	assert.False(t, isLUKSEncrypted(map[string]string{}))
+	assert.Equal(t, "luks-sys1-fs1", luksMapperName("sys1/fs1"))
+
+	_, err := luksPassphrase(map[string]string{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	passphrase, err := luksPassphrase(map[string]string{KeyLUKSPassphrase: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), passphrase)
+}
+
+func TestDeviceSignature(t *testing.T) {
+	assert.True(t, deviceSignature{}.isBlank())
+	assert.False(t, deviceSignature{ptType: "gpt"}.isBlank())
+	assert.Equal(t, "xfs", deviceSignature{fsType: "xfs"}.String())
+	assert.Equal(t, "LVM2_member, dos partition table", deviceSignature{fsType: "LVM2_member", ptType: "dos"}.String())
+	assert.Equal(t, "ambivalent signatures", deviceSignature{ambivalent: true}.String())
+}
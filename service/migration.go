@@ -0,0 +1,174 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// VTree migration states reported by the array
+const (
+	vtreeNotInMigration     = "NotInMigration"
+	vtreeMigrationPaused    = "MigrationPaused"
+	vtreeMigrationDegraded  = "DegradedMigration"
+	vtreeMigrationPauseNone = "None"
+	// goscaleio does not wrap the migrateVTree action
+	vtreeMigrateActionFormat = "/api/instances/Volume::%s/action/migrateVTree"
+)
+
+// vtreeMigrationInfo is the migration state of a VTree
+type vtreeMigrationInfo struct {
+	SourceStoragePoolID      string
+	DestinationStoragePoolID string
+	MigrationStatus          string
+	MigrationPauseReason     string
+	MigrationQueuePosition   int64
+}
+
+type migrateVTreeParam struct {
+	DestSPID string `json:"destSPId"`
+}
+
+// isMigrating returns true while the VTree is queued for or in migration
+func (info *vtreeMigrationInfo) isMigrating() bool {
+	return info != nil && info.MigrationStatus != "" && info.MigrationStatus != vtreeNotInMigration
+}
+
+// migrateBlockVolume starts the online migration of the volume's VTree, with all its snapshots,
+// to the storage pool given in the mutable parameters. The migration runs on the array, its
+// progress is reported by ControllerGetVolume until it completes.
+func (s *service) migrateBlockVolume(ctx context.Context, systemID, csiVolID string, params map[string]string) error {
+	for key := range params {
+		if key != KeyStoragePool && key != KeyProtectionDomain {
+			return status.Errorf(codes.InvalidArgument,
+				"unsupported mutable parameter %s for block volume %s", key, csiVolID)
+		}
+	}
+	poolName := params[KeyStoragePool]
+	if poolName == "" {
+		return status.Errorf(codes.InvalidArgument,
+			"%s is required to migrate block volume %s", KeyStoragePool, csiVolID)
+	}
+
+	pdID, err := s.getProtectionDomainIDFromName(systemID, params[KeyProtectionDomain])
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "protection domain %s not found: %s", params[KeyProtectionDomain], err.Error())
+	}
+	destPoolID, err := s.getStoragePoolID(poolName, systemID, pdID)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "storage pool %s not found: %s", poolName, err.Error())
+	}
+
+	volID := getVolumeIDFromCsiVolumeID(csiVolID)
+	vol, err := s.getVolByID(volID, systemID)
+	if err != nil {
+		if strings.EqualFold(err.Error(), sioGatewayVolumeNotFound) || strings.Contains(err.Error(), "must be a hexadecimal number") {
+			return status.Error(codes.NotFound, "volume not found")
+		}
+		return status.Errorf(codes.Internal, "failure to load volume: %s", err.Error())
+	}
+	if vol.StoragePoolID == destPoolID {
+		log.Infof("Volume %s is already in storage pool %s", csiVolID, poolName)
+		return nil
+	}
+
+	info, err := s.getVTreeMigrationInfo(systemID, vol.VTreeID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not get migration state of volume %s: %s", csiVolID, err.Error())
+	}
+	if info.isMigrating() {
+		if info.DestinationStoragePoolID == destPoolID {
+			log.Infof("Volume %s is already migrating to storage pool %s: %s", csiVolID, poolName, info.MigrationStatus)
+			s.migratingVolumes.Store(systemID+"-"+vol.ID, true)
+			return nil
+		}
+		return status.Errorf(codes.FailedPrecondition,
+			"volume %s is already migrating to storage pool %s", csiVolID, s.getStoragePoolNameFromID(systemID, info.DestinationStoragePoolID))
+	}
+
+	log.Infof("Migrating volume %s (VTree %s) from storage pool %s to %s", csiVolID, vol.VTreeID,
+		s.getStoragePoolNameFromID(systemID, vol.StoragePoolID), poolName)
+	err = s.arrayRESTRequest(ctx, systemID, http.MethodPost, fmt.Sprintf(vtreeMigrateActionFormat, vol.ID),
+		&migrateVTreeParam{DestSPID: destPoolID}, nil)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error starting migration of volume %s: %s", csiVolID, err.Error())
+	}
+	s.migratingVolumes.Store(systemID+"-"+vol.ID, true)
+	s.clearCache()
+	return nil
+}
+
+// getVTreeMigrationInfo returns the migration state of the VTree
+func (s *service) getVTreeMigrationInfo(systemID, vtreeID string) (*vtreeMigrationInfo, error) {
+	if vtreeID == "" {
+		return nil, fmt.Errorf("volume has no VTree")
+	}
+	vtree, err := s.adminClients[systemID].GetVTreeByID(vtreeID)
+	if err != nil {
+		return nil, err
+	}
+	info := vtree.VtreeMigrationInfo
+	return &vtreeMigrationInfo{
+		SourceStoragePoolID:      info.SourceStoragePoolID,
+		DestinationStoragePoolID: info.DestinationStoragePoolID,
+		MigrationStatus:          info.MigrationStatus,
+		MigrationPauseReason:     info.MigrationPauseReason,
+		MigrationQueuePosition:   info.MigrationQueuePosition,
+	}, nil
+}
+
+// getMigrationCondition describes a running migration of the volume's VTree for ControllerGetVolume.
+// It returns an empty message when the volume is not migrating, and abnormal when the migration
+// is paused or degraded. Only the volumes this controller started a migration of are looked up,
+// until the migration completes, so ControllerGetVolume costs no array request for the others.
+func (s *service) getMigrationCondition(systemID string, vol *siotypes.Volume) (*csi.VolumeCondition, bool) {
+	key := systemID + "-" + vol.ID
+	if _, ok := s.migratingVolumes.Load(key); !ok || vol.VTreeID == "" {
+		return nil, false
+	}
+	info, err := s.getVTreeMigrationInfo(systemID, vol.VTreeID)
+	if err != nil {
+		log.Warnf("could not get migration state of volume %s: %s", vol.ID, err.Error())
+		return nil, false
+	}
+	if !info.isMigrating() {
+		s.migratingVolumes.Delete(key)
+		return nil, false
+	}
+	return migrationCondition(info,
+		s.getStoragePoolNameFromID(systemID, info.SourceStoragePoolID),
+		s.getStoragePoolNameFromID(systemID, info.DestinationStoragePoolID)), true
+}
+
+func migrationCondition(info *vtreeMigrationInfo, sourcePool, destPool string) *csi.VolumeCondition {
+	message := fmt.Sprintf("Volume is migrating from storage pool %s to %s, status: %s", sourcePool, destPool, info.MigrationStatus)
+	if info.MigrationQueuePosition > 0 {
+		message += fmt.Sprintf(", queue position: %d", info.MigrationQueuePosition)
+	}
+	if info.MigrationPauseReason != "" && info.MigrationPauseReason != vtreeMigrationPauseNone {
+		message += ", pause reason: " + info.MigrationPauseReason
+	}
+	return &csi.VolumeCondition{
+		Abnormal: info.MigrationStatus == vtreeMigrationPaused || info.MigrationStatus == vtreeMigrationDegraded,
+		Message:  message,
+	}
+}
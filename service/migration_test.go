@@ -0,0 +1,54 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"testing"
+
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVTreeIsMigrating(t *testing.T) {
+	var info *vtreeMigrationInfo
+	assert.False(t, info.isMigrating())
+	assert.False(t, (&vtreeMigrationInfo{}).isMigrating())
+	assert.False(t, (&vtreeMigrationInfo{MigrationStatus: vtreeNotInMigration}).isMigrating())
+	assert.True(t, (&vtreeMigrationInfo{MigrationStatus: "MigrationNormal"}).isMigrating())
+}
+
+func TestMigrationCondition(t *testing.T) {
+	condition := migrationCondition(&vtreeMigrationInfo{
+		MigrationStatus:        "AssignedForMigration",
+		MigrationPauseReason:   vtreeMigrationPauseNone,
+		MigrationQueuePosition: 2,
+	}, "pool1", "pool2")
+	assert.False(t, condition.Abnormal)
+	assert.Equal(t, "Volume is migrating from storage pool pool1 to pool2, status: AssignedForMigration, queue position: 2", condition.Message)
+
+	condition = migrationCondition(&vtreeMigrationInfo{
+		MigrationStatus:      vtreeMigrationPaused,
+		MigrationPauseReason: "MigrationPausedByUser",
+	}, "pool1", "pool2")
+	assert.True(t, condition.Abnormal)
+	assert.Equal(t, "Volume is migrating from storage pool pool1 to pool2, status: MigrationPaused, pause reason: MigrationPausedByUser", condition.Message)
+}
+
+func TestMigrationConditionNotStarted(t *testing.T) {
+	// the array is not asked about volumes no migration was started for
+	s := &service{}
+	condition, migrating := s.getMigrationCondition("sys1", &siotypes.Volume{ID: "vol1", VTreeID: "vtree1"})
+	assert.Nil(t, condition)
+	assert.False(t, migrating)
+}
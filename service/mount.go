@@ -217,8 +217,9 @@ func publishVolume(
 				mntFlags = append(mntFlags, "ro")
 			}
 			fsFormatOption := req.GetVolumeContext()[KeyMkfsFormatOption]
+			allowFormatOverwrite := strings.EqualFold(req.GetVolumeContext()[KeyAllowFormatOverwrite], "true")
 			if err := handlePrivFSMount(
-				ctx, accMode, sysDevice, mntFlags, fs, privTgt, fsFormatOption); err != nil {
+				ctx, accMode, sysDevice, mntFlags, fs, privTgt, fsFormatOption, allowFormatOverwrite); err != nil {
 				// K8S may have removed the desired mount point. Clean up the private target.
 				PrivtgtErr := cleanupPrivateTarget(sysDevice, reqID, privTgt)
 				if PrivtgtErr != nil {
@@ -595,6 +596,7 @@ func handlePrivFSMount(
 	sysDevice *Device,
 	mntFlags []string,
 	fs, privTgt, fsFormatOption string,
+	allowFormatOverwrite bool,
 ) error {
 	// Invoke the formats with a No Discard option to reduce formatting time
 	formatCtx := context.WithValue(ctx, gofsutil.ContextKey(gofsutil.NoDiscard), gofsutil.NoDiscard)
@@ -615,6 +617,10 @@ func handlePrivFSMount(
 		if fsFormatOption != "" {
 			mntFlags = append(mntFlags, "fsFormatOption:"+fsFormatOption)
 		}
+		// Don't leave the format decision to gofsutil for a device holding something else
+		if err := checkDeviceSignature(ctx, sysDevice.FullPath, fs, allowFormatOverwrite); err != nil {
+			return err
+		}
 		if err := gofsutil.FormatAndMount(formatCtx, sysDevice.FullPath, privTgt, fs, mntFlags...); err != nil {
 			return status.Errorf(codes.Internal,
 				"error performing private mount: %s",
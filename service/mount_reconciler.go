@@ -0,0 +1,255 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dell/csmlog"
+	"github.com/dell/gofsutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// file kubelet writes next to the staging mount of a volume, with the handle of the volume
+const stagingVolDataFile = "vol_data.json"
+
+// getStagingPathPrefix returns the directory kubelet stages the volumes of the driver in
+var getStagingPathPrefix = func() string {
+	return filepath.Join("/var/lib/kubelet/plugins/kubernetes.io/csi", Name) + "/"
+}
+
+// startMountReconciler looks for private and staging mounts no pod uses anymore, after a node
+// crash, a kubelet restart or a force-deleted pod, and releases them. The reconciliation is only
+// started when an interval is set, and only logs the mounts unless dry run is turned off.
+func (s *service) startMountReconciler(ctx context.Context) {
+	interval := s.opts.MountReconcileInterval
+	if interval <= 0 {
+		log.Debug("reconciliation of orphaned mounts is disabled")
+		return
+	}
+	log.Infof("reconciling orphaned mounts every %s, dry run %t", interval, s.opts.MountReconcileDryRun)
+
+	// a mount is only released when it is found orphaned by two scans in a row, so volumes being
+	// published when the first scan runs are left alone
+	candidates := s.reconcileMounts(ctx, nil)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			candidates = s.reconcileMounts(ctx, candidates)
+		}
+	}
+}
+
+// reconcileMounts releases the orphaned mounts that were already candidates, and returns the
+// orphaned mounts of this scan as the candidates of the next one
+func (s *service) reconcileMounts(ctx context.Context, candidates map[string]string) map[string]string {
+	mounts, err := gofsutil.GetMounts(ctx)
+	if err != nil {
+		log.Errorf("unable to get mounts to reconcile: %s", err.Error())
+		return candidates
+	}
+
+	// inline ephemeral volumes are neither in a vol_data.json nor in a VolumeAttachment, so their
+	// staging mounts are left alone
+	orphaned := make(map[string]string)
+	var attached map[string]bool
+	var attachedErr error
+	for _, m := range findOrphanedMounts(mounts, s.privDir, []string{getStagingPathPrefix()}) {
+		device := mountDevice(m)
+		if strings.HasPrefix(m.Path, getStagingPathPrefix()) {
+			// kubelet still tracks the volume until it removes vol_data.json or the volume is detached
+			handle, err := getStagedVolumeHandle(m.Path)
+			if err != nil {
+				log.Errorf("unable to read the volume of staging mount %s, keeping it: %s", m.Path, err.Error())
+				continue
+			}
+			if handle != "" && attached == nil && attachedErr == nil {
+				if attached, attachedErr = getAttachedVolumeHandles(ctx, s.opts.KubeNodeName); attachedErr != nil {
+					log.Errorf("unable to get the volume attachments of the node, keeping the staging mounts: %s", attachedErr.Error())
+				}
+			}
+			if handle != "" && (attachedErr != nil || attached[handle]) {
+				log.Debugf("staging mount %s of device %s has no pod target but volume %s is still attached", m.Path, device, handle)
+				continue
+			}
+		}
+		orphaned[m.Path] = device
+		if candidates[m.Path] != device {
+			log.Infof("mount %s of device %s has no pod target, releasing it if still unused at the next scan", m.Path, device)
+			continue
+		}
+		s.releaseOrphanedMount(ctx, m.Path, device)
+		delete(orphaned, m.Path)
+	}
+	return orphaned
+}
+
+// findOrphanedMounts returns the mounts in the private directory, or in one of the staging
+// directories, whose device is not mounted to any pod target path
+func findOrphanedMounts(mounts []gofsutil.Info, privDir string, stagingDirs []string) []gofsutil.Info {
+	used := make(map[string]bool)
+	for _, m := range mounts {
+		if strings.HasPrefix(m.Path, getTargetPathPrefix()) {
+			used[mountDevice(m)] = true
+		}
+	}
+
+	var orphaned []gofsutil.Info
+	for _, m := range mounts {
+		if !isDriverMount(m.Path, privDir, stagingDirs) || used[mountDevice(m)] {
+			continue
+		}
+		orphaned = append(orphaned, m)
+	}
+	return orphaned
+}
+
+// getStagedVolumeHandle returns the handle of the volume kubelet staged at a staging mount, from
+// the vol_data.json of the staging path, or "" when kubelet removed the file
+func getStagedVolumeHandle(stagingPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(filepath.Clean(stagingPath)), stagingVolDataFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	volData := struct {
+		VolumeHandle string `json:"volumeHandle"`
+	}{}
+	if err := json.Unmarshal(data, &volData); err != nil {
+		return "", err
+	}
+	if volData.VolumeHandle == "" {
+		return "", fmt.Errorf("no volume handle in %s", stagingVolDataFile)
+	}
+	return volData.VolumeHandle, nil
+}
+
+// getAttachedVolumeHandles returns the handles of the volumes of the driver with a VolumeAttachment
+// to the node
+func getAttachedVolumeHandles(ctx context.Context, nodeName string) (map[string]bool, error) {
+	if K8sClientset == nil {
+		return nil, errors.New("no kubernetes client")
+	}
+	if nodeName == "" {
+		return nil, errors.New("the kubernetes node name is not set")
+	}
+	attachments, err := K8sClientset.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	handles := make(map[string]bool)
+	for _, va := range attachments.Items {
+		if va.Spec.Attacher != Name || va.Spec.NodeName != nodeName {
+			continue
+		}
+		source := va.Spec.Source
+		if source.InlineVolumeSpec != nil && source.InlineVolumeSpec.CSI != nil {
+			handles[source.InlineVolumeSpec.CSI.VolumeHandle] = true
+		}
+		if source.PersistentVolumeName == nil {
+			continue
+		}
+		pv, err := K8sClientset.CoreV1().PersistentVolumes().Get(ctx, *source.PersistentVolumeName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pv.Spec.CSI != nil {
+			handles[pv.Spec.CSI.VolumeHandle] = true
+		}
+	}
+	return handles, nil
+}
+
+// isDriverMount returns true for the private mounts and staging mounts of the driver
+func isDriverMount(path, privDir string, stagingDirs []string) bool {
+	if privDir != "" && filepath.Dir(path) == filepath.Clean(privDir) {
+		return true
+	}
+	for _, dir := range stagingDirs {
+		if dir != "" && strings.HasPrefix(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// mountDevice returns the device of the mount, including block volumes bind mounted from devtmpfs
+func mountDevice(m gofsutil.Info) string {
+	if m.Device == "devtmpfs" || m.Device == "udev" {
+		return m.Source
+	}
+	return m.Device
+}
+
+// releaseOrphanedMount unmounts an orphaned mount after checking again that no pod target uses
+// its device, and disconnects the NVMe device left without mounts. SDC devices are released
+// when the controller unmaps the volume.
+func (s *service) releaseOrphanedMount(ctx context.Context, path, device string) {
+	fields := csmlog.Fields{"path": path, "device": device, "dryRun": s.opts.MountReconcileDryRun}
+	mounts, err := gofsutil.GetMounts(ctx)
+	if err != nil {
+		log.WithFields(fields).Errorf("unable to check orphaned mount: %s", err.Error())
+		return
+	}
+	deviceMounts := 0
+	for _, m := range mounts {
+		if mountDevice(m) != device {
+			continue
+		}
+		if strings.HasPrefix(m.Path, getTargetPathPrefix()) {
+			log.WithFields(fields).Infof("device is mounted again to %s, keeping the mount", m.Path)
+			return
+		}
+		deviceMounts++
+	}
+
+	if s.opts.MountReconcileDryRun {
+		log.WithFields(fields).Warn("orphaned mount would be unmounted")
+		return
+	}
+	log.WithFields(fields).Warn("unmounting orphaned mount")
+	if err := gofsutil.Unmount(ctx, path); err != nil {
+		log.WithFields(fields).Errorf("unable to unmount orphaned mount: %s", err.Error())
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.WithFields(fields).Errorf("unable to remove directory of orphaned mount: %s", err.Error())
+	}
+
+	devName := strings.TrimPrefix(device, "/dev/")
+	if deviceMounts > 1 || !strings.HasPrefix(devName, "nvme") || s.nvmeConnector == nil {
+		return
+	}
+	log.WithFields(fields).Warn("disconnecting NVMe device of orphaned mount")
+	if err := s.nvmeConnector.DisconnectVolumeByDeviceName(ctx, devName); err != nil {
+		log.WithFields(fields).Errorf("unable to disconnect NVMe device of orphaned mount: %s", err.Error())
+	}
+}
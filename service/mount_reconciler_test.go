@@ -0,0 +1,122 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dell/gofsutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFindOrphanedMounts(t *testing.T) {
+	privDir := "/dev/disk/csi-vxflexos"
+	stagingDir := getStagingPathPrefix()
+	podDir := getTargetPathPrefix() + "0d4c6d6e-3c0a-4a43-9a4b-6d7cfa2c0b11/volumes/kubernetes.io~csi/"
+
+	mounts := []gofsutil.Info{
+		// published filesystem volume
+		{Device: "/dev/scinia", Path: privDir + "/sys1-vol1"},
+		{Device: "/dev/scinia", Path: podDir + "pvc-1/mount"},
+		// private mount left by a force-deleted pod
+		{Device: "/dev/scinib", Path: privDir + "/sys1-vol2"},
+		// published NVMe block volume, the staging mount is in use
+		{Device: "/dev/nvme0n1", Path: stagingDir + "abc/globalmount"},
+		{Device: "devtmpfs", Source: "/dev/nvme0n1", Path: podDir + "pvc-3/dev"},
+		// staging mount left after a kubelet restart
+		{Device: "/dev/nvme0n2", Path: stagingDir + "def/globalmount"},
+		// not a driver mount
+		{Device: "/dev/sda1", Path: "/var/lib/other"},
+	}
+
+	orphaned := findOrphanedMounts(mounts, privDir, []string{stagingDir, ephemeralStagingMountPath})
+	assert.Equal(t, []gofsutil.Info{
+		{Device: "/dev/scinib", Path: privDir + "/sys1-vol2"},
+		{Device: "/dev/nvme0n2", Path: stagingDir + "def/globalmount"},
+	}, orphaned)
+}
+
+func TestIsDriverMount(t *testing.T) {
+	assert.True(t, isDriverMount("/dev/disk/csi-vxflexos/sys1-vol1", "/dev/disk/csi-vxflexos/", nil))
+	assert.False(t, isDriverMount("/dev/disk/csi-vxflexos/sys1-vol1/sub", "/dev/disk/csi-vxflexos", nil))
+	assert.True(t, isDriverMount(ephemeralStagingMountPath+"vol1", "", []string{ephemeralStagingMountPath}))
+	assert.False(t, isDriverMount("/mnt/vol1", "/dev/disk/csi-vxflexos", []string{""}))
+}
+
+func TestGetStagedVolumeHandle(t *testing.T) {
+	stagingPath := filepath.Join(t.TempDir(), "abc", "globalmount")
+	assert.NoError(t, os.MkdirAll(stagingPath, 0o750))
+
+	// kubelet removed vol_data.json, the volume is gone
+	handle, err := getStagedVolumeHandle(stagingPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "", handle)
+
+	volData := filepath.Join(filepath.Dir(stagingPath), stagingVolDataFile)
+	assert.NoError(t, os.WriteFile(volData, []byte(`{"driverName":"csi-vxflexos.dellemc.com","volumeHandle":"sys1-vol1"}`), 0o600))
+	handle, err = getStagedVolumeHandle(stagingPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "sys1-vol1", handle)
+
+	assert.NoError(t, os.WriteFile(volData, []byte(`{"driverName":"csi-vxflexos.dellemc.com"}`), 0o600))
+	_, err = getStagedVolumeHandle(stagingPath)
+	assert.Error(t, err)
+}
+
+func TestGetAttachedVolumeHandles(t *testing.T) {
+	defer func() { K8sClientset = nil }()
+	_, err := getAttachedVolumeHandles(context.Background(), "node1")
+	assert.Error(t, err)
+
+	pvName := func(name string) *string { return &name }
+	attachment := func(name, attacher, node, pv string) *storagev1.VolumeAttachment {
+		return &storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: attacher,
+				NodeName: node,
+				Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: pvName(pv)},
+			},
+		}
+	}
+	pv := func(name, handle string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: Name, VolumeHandle: handle},
+			}},
+		}
+	}
+	K8sClientset = fake.NewSimpleClientset(
+		attachment("va1", Name, "node1", "pv1"),
+		attachment("va2", Name, "node2", "pv2"),
+		attachment("va3", "other.csi.example.com", "node1", "pv3"),
+		// the PersistentVolume was deleted while the attachment is being removed
+		attachment("va4", Name, "node1", "pv4"),
+		pv("pv1", "sys1-vol1"),
+		pv("pv2", "sys1-vol2"),
+		pv("pv3", "sys1-vol3"),
+	)
+
+	handles, err := getAttachedVolumeHandles(context.Background(), "node1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"sys1-vol1": true}, handles)
+}
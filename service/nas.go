@@ -0,0 +1,161 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dell/goscaleio"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NAS server selection policies, used when more than one NAS server is given for NFS volumes
+const (
+	// NasPolicyRoundRobin cycles through the NAS servers in the order they are listed
+	NasPolicyRoundRobin = "RoundRobin"
+
+	// NasPolicyLeastFilesystems picks the NAS server hosting the fewest filesystems
+	NasPolicyLeastFilesystems = "LeastFilesystems"
+
+	// NasPolicyLeastUsedCapacity picks the NAS server whose filesystems use the least capacity
+	NasPolicyLeastUsedCapacity = "LeastUsedCapacity"
+)
+
+// parseNasNames splits a comma separated list of NAS server names, dropping empty and duplicate names
+func parseNasNames(nasNames string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(nasNames, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validateNasSelectionPolicy returns the selection policy to use, defaulting to round robin
+func validateNasSelectionPolicy(policy string) (string, error) {
+	switch {
+	case policy == "":
+		return NasPolicyRoundRobin, nil
+	case strings.EqualFold(policy, NasPolicyRoundRobin):
+		return NasPolicyRoundRobin, nil
+	case strings.EqualFold(policy, NasPolicyLeastFilesystems):
+		return NasPolicyLeastFilesystems, nil
+	case strings.EqualFold(policy, NasPolicyLeastUsedCapacity):
+		return NasPolicyLeastUsedCapacity, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "invalid %s: %s, allowed values are %s, %s and %s",
+		KeyNasSelectionPolicy, policy, NasPolicyRoundRobin, NasPolicyLeastFilesystems, NasPolicyLeastUsedCapacity)
+}
+
+// getNASServers returns the NAS servers with the given names on the system. Servers that cannot be
+// found are skipped, so one removed server does not prevent provisioning on the others.
+func (s *service) getNASServers(system *goscaleio.System, nasNames []string) ([]*siotypes.NAS, error) {
+	if len(nasNames) == 0 {
+		log.Infof("NAS server not provided.")
+		return nil, status.Error(codes.InvalidArgument, "NAS server not provided")
+	}
+
+	nasServers := make([]*siotypes.NAS, 0, len(nasNames))
+	var lastErr error
+	for _, name := range nasNames {
+		nas, err := system.GetNASByIDName("", name)
+		if err != nil {
+			log.Warnf("Skipping NAS server %s: %v", name, err)
+			lastErr = err
+			continue
+		}
+		nasServers = append(nasServers, nas)
+	}
+	if len(nasServers) == 0 {
+		return nil, status.Errorf(codes.NotFound, "none of the NAS servers %v was found: %v", nasNames, lastErr)
+	}
+	return nasServers, nil
+}
+
+// findNASServerByID returns the NAS server with the given ID, or nil if it is not in the list
+func findNASServerByID(nasServers []*siotypes.NAS, id string) *siotypes.NAS {
+	for _, nas := range nasServers {
+		if nas.ID == id {
+			return nas
+		}
+	}
+	return nil
+}
+
+// selectNASServer picks the NAS server for a new filesystem from the online servers in nasServers
+func (s *service) selectNASServer(system *goscaleio.System, systemID string, nasServers []*siotypes.NAS, policy string) (*siotypes.NAS, error) {
+	online := make([]*siotypes.NAS, 0, len(nasServers))
+	for _, nas := range nasServers {
+		if !strings.EqualFold(nas.OperationalStatus, nasServerStarted) {
+			log.Warnf("Skipping NAS server %s with operational status: %s", nas.Name, nas.OperationalStatus)
+			continue
+		}
+		online = append(online, nas)
+	}
+
+	if len(online) == 0 {
+		return nil, status.Errorf(codes.Unavailable, "none of the NAS servers %v is online", nasServerNames(nasServers))
+	}
+	if len(online) == 1 {
+		return online[0], nil
+	}
+
+	if policy == NasPolicyRoundRobin {
+		key := systemID + ":" + strings.Join(nasServerNames(nasServers), ",")
+		counter, _ := s.nasSelectionCounters.LoadOrStore(key, new(atomic.Uint64))
+		next := counter.(*atomic.Uint64).Add(1) - 1
+		return online[next%uint64(len(online))], nil
+	}
+
+	filesystems, err := system.GetAllFileSystems()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to list filesystems to select NAS server: %v", err)
+	}
+	return selectNASServerByLoad(online, filesystems, policy), nil
+}
+
+// selectNASServerByLoad returns the NAS server with the fewest filesystems or least used capacity,
+// depending on policy. Ties go to the server listed first.
+func selectNASServerByLoad(nasServers []*siotypes.NAS, filesystems []siotypes.FileSystem, policy string) *siotypes.NAS {
+	load := make(map[string]int64, len(nasServers))
+	for _, fs := range filesystems {
+		if policy == NasPolicyLeastUsedCapacity {
+			load[fs.NasServerID] += int64(fs.SizeUsed)
+		} else {
+			load[fs.NasServerID]++
+		}
+	}
+
+	sorted := make([]*siotypes.NAS, len(nasServers))
+	copy(sorted, nasServers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return load[sorted[i].ID] < load[sorted[j].ID]
+	})
+	log.Infof("NAS server %s selected by %s policy, load: %d", sorted[0].Name, policy, load[sorted[0].ID])
+	return sorted[0]
+}
+
+func nasServerNames(nasServers []*siotypes.NAS) []string {
+	names := make([]string, 0, len(nasServers))
+	for _, nas := range nasServers {
+		names = append(names, nas.Name)
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"testing"
+
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNasNames(t *testing.T) {
+	assert.Equal(t, []string{"nas1"}, parseNasNames("nas1"))
+	assert.Equal(t, []string{"nas1", "nas2"}, parseNasNames(" nas1, nas2 ,nas1,"))
+	assert.Empty(t, parseNasNames(""))
+}
+
+func TestValidateNasSelectionPolicy(t *testing.T) {
+	tests := []struct {
+		policy  string
+		want    string
+		wantErr bool
+	}{
+		{policy: "", want: NasPolicyRoundRobin},
+		{policy: "roundrobin", want: NasPolicyRoundRobin},
+		{policy: "LeastFilesystems", want: NasPolicyLeastFilesystems},
+		{policy: "leastUsedCapacity", want: NasPolicyLeastUsedCapacity},
+		{policy: "random", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			got, err := validateNasSelectionPolicy(tt.policy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSelectNASServerByLoad(t *testing.T) {
+	nas1 := &siotypes.NAS{ID: "nas1-id", Name: "nas1"}
+	nas2 := &siotypes.NAS{ID: "nas2-id", Name: "nas2"}
+	filesystems := []siotypes.FileSystem{
+		{NasServerID: "nas1-id", SizeUsed: 10},
+		{NasServerID: "nas1-id", SizeUsed: 10},
+		{NasServerID: "nas2-id", SizeUsed: 100},
+	}
+
+	assert.Equal(t, nas2, selectNASServerByLoad([]*siotypes.NAS{nas1, nas2}, filesystems, NasPolicyLeastFilesystems))
+	assert.Equal(t, nas1, selectNASServerByLoad([]*siotypes.NAS{nas1, nas2}, filesystems, NasPolicyLeastUsedCapacity))
+	// ties go to the first listed server
+	assert.Equal(t, nas1, selectNASServerByLoad([]*siotypes.NAS{nas1, nas2}, nil, NasPolicyLeastFilesystems))
+}
+
+func TestSelectNASServerSkipsOffline(t *testing.T) {
+	s := &service{}
+	online := &siotypes.NAS{ID: "nas1-id", Name: "nas1", OperationalStatus: nasServerStarted}
+	offline := &siotypes.NAS{ID: "nas2-id", Name: "nas2", OperationalStatus: "Stopped"}
+
+	nas, err := s.selectNASServer(nil, "sys", []*siotypes.NAS{offline, online}, NasPolicyRoundRobin)
+	assert.NoError(t, err)
+	assert.Equal(t, online, nas)
+
+	_, err = s.selectNASServer(nil, "sys", []*siotypes.NAS{offline}, NasPolicyRoundRobin)
+	assert.Error(t, err)
+}
+
+func TestSelectNASServerRoundRobin(t *testing.T) {
+	s := &service{}
+	nas1 := &siotypes.NAS{ID: "nas1-id", Name: "nas1", OperationalStatus: nasServerStarted}
+	nas2 := &siotypes.NAS{ID: "nas2-id", Name: "nas2", OperationalStatus: nasServerStarted}
+	servers := []*siotypes.NAS{nas1, nas2}
+
+	for _, want := range []*siotypes.NAS{nas1, nas2, nas1} {
+		nas, err := s.selectNASServer(nil, "sys", servers, NasPolicyRoundRobin)
+		assert.NoError(t, err)
+		assert.Equal(t, want, nas)
+	}
+}
@@ -50,11 +50,17 @@ var (
 
 const (
 	maxVxflexosVolumesPerNodeLabel = "max-vxflexos-volumes-per-node"
+
+	// nfsStatTimeout bounds the stat used to check an NFS mount is responsive
+	nfsStatTimeout = 5 * time.Second
 )
 
 func (s *service) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
-	if !s.useNVME {
-		// This stage path is a no-op for SDC nodes
+	if !s.useNVME || !s.isNVMeSystem(s.getSystemIDFromCsiVolumeID(req.GetVolumeId())) {
+		if isLUKSEncrypted(req.GetVolumeContext()) {
+			return s.stageLUKSVolume(ctx, req)
+		}
+		// This stage path is a no-op for SDC volumes
 		// Return OK to preserve idempotency semantics if upper layers still call Stage.
 		return &csi.NodeStageVolumeResponse{}, nil
 	}
@@ -97,14 +103,25 @@ func (s *service) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeR
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	var stager VolumeStager
-	stager = &NVMeStager{
-		useNVME:       s.useNVME,
+	nvmeStager := &NVMeStager{
+		useNVME:       s.isNVMeSystem(systemID),
 		systemID:      systemID,
 		nvmeConnector: s.nvmeConnector,
 		targetNqn:     s.nvmeTargetNqn,
 		adminClient:   s.adminClients[systemID],
+		portalFilter:  s.getNVMePortalFilter(systemID),
+	}
+	if s.opts.NVMeStageRepair {
+		nvmeStager.repairs = &s.nvmeStageRepairs
+	}
+	if isLUKSEncrypted(req.GetVolumeContext()) {
+		passphrase, err := luksPassphrase(req.GetSecrets())
+		if err != nil {
+			return nil, err
+		}
+		nvmeStager.luksPassphrase = passphrase
 	}
+	var stager VolumeStager = nvmeStager
 	response, err := stager.Stage(ctx, req, stagingPath, logFields, volID)
 	return response, err
 }
@@ -149,13 +166,22 @@ func (s *service) NodeUnstageVolume(
 	}
 
 	// Calling NVMeStager to unstage volume
-	if s.useNVME {
+	if s.isNVMeSystem(s.getSystemIDFromCsiVolumeID(csiVolID)) {
 		var stager VolumeStager
 		stager = &NVMeStager{
-			useNVME:       s.useNVME,
+			useNVME:       true,
 			nvmeConnector: s.nvmeConnector,
 		}
 		response, err := stager.Unstage(ctx, stagingTargetPath, fields, csiVolID)
+		if err != nil {
+			err = s.handleBusyUnmount(ctx, csiVolID, "", []string{stagingTargetPath}, err, func() error {
+				_, err := stager.Unstage(ctx, stagingTargetPath, fields, csiVolID)
+				return err
+			})
+			if err == nil {
+				return &csi.NodeUnstageVolumeResponse{}, nil
+			}
+		}
 		return response, err
 	}
 
@@ -165,6 +191,11 @@ func (s *service) NodeUnstageVolume(
 		log.Errorf("Unable to Unmount staging target path: %s", err)
 	}
 
+	if err := luksClose(ctx, csiVolID); err != nil {
+		log.WithFields(fields).Error(err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
 	log.WithFields(fields).Info("removing directory")
 	if err := os.Remove(stagingTargetPath); err != nil && !os.IsNotExist(err) {
 		log.Errorf("Unable to remove staging target path: %v", err)
@@ -215,7 +246,7 @@ func (s *service) NodePublishVolume(
 	// Check for NFS protocol
 	fsType := volumeContext[KeyFsType]
 	isNFS := false
-	if fsType == "nfs" {
+	if isFileFsType(fsType) {
 		isNFS = true
 	}
 
@@ -257,6 +288,13 @@ func (s *service) NodePublishVolume(
 			}
 		}
 
+		if fsType == SMBFsType {
+			if err := publishSMB(ctx, req, req.GetPublishContext()[KeySMBShare]); err != nil {
+				return nil, err
+			}
+			return &csi.NodePublishVolumeResponse{}, nil
+		}
+
 		client := s.adminClients[systemID]
 
 		NFSExport, err := s.getNFSExport(fs, client)
@@ -280,7 +318,7 @@ func (s *service) NodePublishVolume(
 		return &csi.NodePublishVolumeResponse{}, nil
 	}
 
-	if s.useNVME {
+	if s.isNVMeSystem(systemID) {
 		nguid, err := buildNGUID(volID, systemID)
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to build NGUID: %s", err.Error())
@@ -292,6 +330,11 @@ func (s *service) NodePublishVolume(
 			log.Error(errmsg)
 			return nil, status.Error(codes.NotFound, errmsg)
 		}
+		if isLUKSEncrypted(volumeContext) {
+			if symlinkPath = luksDevicePath(csiVolID); symlinkPath == "" {
+				return nil, status.Errorf(codes.FailedPrecondition, "LUKS device of volume %s is not open, the volume is not staged", csiVolID)
+			}
+		}
 
 		if err := publishNVMEVolume(req, reqID, symlinkPath); err != nil {
 			return nil, err
@@ -302,7 +345,14 @@ func (s *service) NodePublishVolume(
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 
-		if err := publishVolume(req, s.privDir, sdcMappedVol.SdcDevice, reqID); err != nil {
+		device := sdcMappedVol.SdcDevice
+		if isLUKSEncrypted(volumeContext) {
+			if device = luksDevicePath(csiVolID); device == "" {
+				return nil, status.Errorf(codes.FailedPrecondition, "LUKS device of volume %s is not open, the volume is not staged", csiVolID)
+			}
+		}
+
+		if err := publishVolume(req, s.privDir, device, reqID); err != nil {
 			return nil, err
 		}
 	}
@@ -357,6 +407,15 @@ func (s *service) NodeUnpublishVolume(
 	}
 
 	if isNFS {
+		// SMB shares are recognized by their mount, the filesystem and its share may already be gone
+		smbUnpublished, err := unpublishSMB(ctx, targetPath)
+		if err != nil {
+			return nil, err
+		}
+		if smbUnpublished {
+			return &csi.NodeUnpublishVolumeResponse{}, nil
+		}
+
 		fsID := getFilesystemIDFromCsiVolumeID(csiVolID)
 		log.Infof("NodeUnpublishVolume fileSystemID: %s", fsID)
 
@@ -425,7 +484,7 @@ func (s *service) NodeUnpublishVolume(
 			"checkVolumesMap for id: %s failed : %s", csiVolID, err.Error())
 	}
 
-	if s.useNVME {
+	if s.isNVMeSystem(systemID) {
 		log.Infof("NodeUnpublishVolume: NVME volume %s, doing mount cleanup", csiVolID)
 
 		if ephemeralVolume {
@@ -438,7 +497,12 @@ func (s *service) NodeUnpublishVolume(
 		}
 
 		if err := unpublishNVMEVolume(csiVolID, targetPath, reqID); err != nil {
-			return nil, err
+			err = s.handleBusyUnmount(ctx, csiVolID, "", []string{targetPath}, err, func() error {
+				return unpublishNVMEVolume(csiVolID, targetPath, reqID)
+			})
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		// Idempotent need to return ok if not published
@@ -473,8 +537,20 @@ func (s *service) NodeUnpublishVolume(
 		return &csi.NodeUnpublishVolumeResponse{}, nil
 	}
 
-	if err := unpublishVolume(csiVolID, req.GetTargetPath(), s.privDir, sdcMappedVol.SdcDevice, reqID); err != nil {
-		return nil, err
+	// the filesystem of an encrypted volume is on its LUKS device
+	device := sdcMappedVol.SdcDevice
+	if luksDevice := luksDevicePath(csiVolID); luksDevice != "" {
+		device = luksDevice
+	}
+
+	if err := unpublishVolume(csiVolID, req.GetTargetPath(), s.privDir, device, reqID); err != nil {
+		paths := []string{req.GetTargetPath(), getPrivateMountPoint(s.privDir, csiVolID)}
+		err = s.handleBusyUnmount(ctx, csiVolID, device, paths, err, func() error {
+			return unpublishVolume(csiVolID, req.GetTargetPath(), s.privDir, device, reqID)
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if ephemeralVolume {
@@ -556,7 +632,7 @@ func (s *service) getSystemName(_ context.Context, systems []string) bool {
 // It also makes sure private directory(privDir) is created
 func (s *service) nodeProbe(ctx context.Context) error {
 	// skip SDC based probe if it is pure NVMe
-	if s.useNVME == true {
+	if s.useNVME && !s.useSDC && !s.hasAutoBlockProtocol() {
 		log.Info("skipping SDC based probe as the node is pure NVMe")
 		return nil
 	}
@@ -963,12 +1039,8 @@ func (s *service) NodeGetInfo(
 		return nil, status.Error(codes.InvalidArgument, GetMessage("Could not fetch node UID"))
 	}
 
-	if s.opts.SdcGUID != "" {
-		nodeID = s.opts.SdcGUID
-	}
-
-	if s.useNVME {
-		nodeID = s.nodeID
+	if id := s.getNodeID(); id != "" {
+		nodeID = id
 	}
 
 	for _, array := range s.opts.arrays {
@@ -979,6 +1051,9 @@ func (s *service) NodeGetInfo(
 		}
 		if isNFSEnabled {
 			topology[Name+"/"+array.SystemID+"-nfs"] = "true"
+			if smbMountSupported() {
+				topology[Name+"/"+array.SystemID+"-smb"] = "true"
+			}
 		}
 		if zone, ok := topology[s.opts.zoneLabelKey]; ok {
 			if zone == string(array.AvailabilityZone.Name) {
@@ -1004,7 +1079,7 @@ func (s *service) NodeGetInfo(
 
 func (s *service) populateNodeTopology(topology map[string]string, systemID string) {
 	// Check if NVMe protocol is enabled on the array
-	if s.useNVME {
+	if s.isNVMeSystem(systemID) {
 		system := s.systems[systemID]
 		_, err := s.discoverNVMeTargets(system)
 		if err != nil {
@@ -1021,6 +1096,7 @@ func (s *service) populateNodeTopology(topology map[string]string, systemID stri
 // if volume is healthy, stats on volume usage will be returned
 // if volume is unhealthy, a message will be returned detailing the issue
 // To determine if volume is healthy, this method checks: volume known to array, volume known to SDC, volume path readable, and volume path mounted
+// NFS volumes are checked by nodeGetNFSVolumeStats
 // Note: kubelet only calls this method when feature gate: CSIVolumeHealth=true
 func (s *service) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
 	csiVolID := req.GetVolumeId()
@@ -1060,27 +1136,36 @@ func (s *service) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolume
 		return nil, err
 	}
 
-	_, err := s.getSDCMappedVol(volID, systemID, 30)
-	if err != nil {
-		// volume not known to SDC, next check if it exists at all
-		_, _, err := s.listVolumes(systemID, 0, 0, false, false, volID, "")
-		if err != nil && strings.Contains(err.Error(), sioGatewayVolumeNotFound) {
-			message = fmt.Sprintf("Volume is not found by node driver at %s", time.Now().Format("2006-01-02 15:04:05"))
-		} else if err != nil {
-			// error was returned, but had nothing to do with the volume not being on the array (may be env related)
-			return nil, err
-		}
-		// volume was found, but was not known to SDC. This is abnormal.
-		healthy = false
-		if message == "" {
-			message = fmt.Sprintf("volume: %s was not mapped to host: %v", volID, err)
-		}
+	// NFS volumes are not mapped through the SDC, check them against the filesystem and export instead
+	if strings.Contains(csiVolID, "/") {
+		return s.nodeGetNFSVolumeStats(ctx, csiVolID, systemID, volPath)
+	}
+
+	// volumes connected through NVMe/TCP are not known to the SDC
+	nvmePaths, isNVMe := s.checkNVMeVolumePaths(volID, systemID)
+	if !isNVMe {
+		_, err := s.getSDCMappedVol(volID, systemID, 30)
+		if err != nil {
+			// volume not known to SDC, next check if it exists at all
+			_, _, err := s.listVolumes(systemID, 0, 0, false, false, volID, "")
+			if err != nil && strings.Contains(err.Error(), sioGatewayVolumeNotFound) {
+				message = fmt.Sprintf("Volume is not found by node driver at %s", time.Now().Format("2006-01-02 15:04:05"))
+			} else if err != nil {
+				// error was returned, but had nothing to do with the volume not being on the array (may be env related)
+				return nil, err
+			}
+			// volume was found, but was not known to SDC. This is abnormal.
+			healthy = false
+			if message == "" {
+				message = fmt.Sprintf("volume: %s was not mapped to host: %v", volID, err)
+			}
 
+		}
 	}
 
 	// check if volume path is accessible
 	if healthy {
-		_, err = os.ReadDir(volPath)
+		_, err := os.ReadDir(volPath)
 		if err != nil && healthy {
 			healthy = false
 			message = fmt.Sprintf("volume path: %s is not accessible: %v", volPath, err)
@@ -1106,6 +1191,22 @@ func (s *service) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolume
 
 	}
 
+	resp := volumeStatsResponse(ctx, volPath, healthy, message)
+	// a volume on fewer paths is still usable, its usage is reported along with the condition
+	if isNVMe && nvmePaths.degraded() && !resp.VolumeCondition.Abnormal {
+		resp.VolumeCondition = &csi.VolumeCondition{
+			Abnormal: true,
+			Message: fmt.Sprintf("volume is degraded, %d of %d NVMe/TCP paths are live on device %s",
+				nvmePaths.Live, nvmePaths.Expected, nvmePaths.Device),
+		}
+	}
+	return resp, nil
+}
+
+// volumeStatsResponse builds the NodeGetVolumeStats response for volPath. Usage is
+// only collected when the volume is healthy; otherwise an UNKNOWN usage is returned
+// along with the abnormal condition and message.
+func volumeStatsResponse(ctx context.Context, volPath string, healthy bool, message string) *csi.NodeGetVolumeStatsResponse {
 	if healthy {
 
 		availableBytes, totalBytes, usedBytes, totalInodes, freeInodes, usedInodes, err := gofsutil.FsInfo(ctx, volPath)
@@ -1123,7 +1224,7 @@ func (s *service) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolume
 					Abnormal: true,
 					Message:  fmt.Sprintf("failed to get metrics for volume with error: %v", err),
 				},
-			}, nil
+			}
 		}
 		return &csi.NodeGetVolumeStatsResponse{
 			Usage: []*csi.VolumeUsage{
@@ -1144,7 +1245,7 @@ func (s *service) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolume
 				Abnormal: !healthy,
 				Message:  message,
 			},
-		}, nil
+		}
 
 	}
 
@@ -1161,7 +1262,135 @@ func (s *service) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolume
 			Abnormal: !healthy,
 			Message:  message,
 		},
-	}, nil
+	}
+}
+
+// nodeGetNFSVolumeStats is the NodeGetVolumeStats path for NFS and SMB volumes (csiVolID of the form systemID/fsID).
+// To determine if the volume is healthy, this method checks: filesystem known to array, NFS export
+// still grants access to this node or SMB share of this node still exists, mount responsive, and
+// volume path mounted
+func (s *service) nodeGetNFSVolumeStats(ctx context.Context, csiVolID, systemID, volPath string) (*csi.NodeGetVolumeStatsResponse, error) {
+	healthy := true
+	message := ""
+
+	fsID := getFilesystemIDFromCsiVolumeID(csiVolID)
+	fs, err := s.getFilesystemByID(fsID, systemID)
+	if err != nil {
+		if strings.EqualFold(err.Error(), sioGatewayFileSystemNotFound) || strings.Contains(err.Error(), sioGatewayNotFound) {
+			message = fmt.Sprintf("Filesystem is not found by node driver at %s", time.Now().Format("2006-01-02 15:04:05"))
+			return volumeStatsResponse(ctx, volPath, false, message), nil
+		}
+		// error was returned, but had nothing to do with the filesystem not being on the array (may be env related)
+		return nil, err
+	}
+
+	mounts, mountErr := getPathMounts(ctx, volPath)
+	if shareName := smbMountShareName(mounts); shareName != "" {
+		// SMB volumes have a share for each node instead of an NFS export, check the share mounted here
+		shares, err := s.getSMBShares(ctx, systemID, fs)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "could not get the SMB shares of filesystem: %s: %v", fs.Name, err)
+		}
+		found := false
+		for _, share := range shares {
+			found = found || share.Name == shareName
+		}
+		if !found {
+			healthy = false
+			message = fmt.Sprintf("SMB share: %s of filesystem: %s was not found", shareName, fs.Name)
+		}
+	} else {
+		// check that the NFS export still lists one of this node's IPs
+		export, err := s.getNFSExport(fs, s.adminClients[systemID])
+		if err != nil {
+			if status.Code(err) != codes.NotFound {
+				return nil, err
+			}
+			healthy = false
+			message = fmt.Sprintf("NFS export for filesystem: %s was not found", fs.Name)
+		}
+
+		if healthy {
+			nodeIPs, err := s.getNodeIP()
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "could not determine node IPs: %v", err)
+			}
+			if !nfsExportHasHost(export, nodeIPs) {
+				healthy = false
+				message = fmt.Sprintf("NFS export: %s does not grant access to node IPs: %v", export.Name, nodeIPs)
+			}
+		}
+	}
+
+	// check that the NFS or SMB mount is live and responsive
+	if healthy {
+		if err := statWithTimeout(volPath, nfsStatTimeout); err != nil {
+			healthy = false
+			message = fmt.Sprintf("volume path: %s is not accessible: %v", volPath, err)
+		}
+	}
+
+	if healthy && (len(mounts) == 0 || mountErr != nil) {
+		healthy = false
+		message = fmt.Sprintf("volPath: %s is not mounted: %v", volPath, mountErr)
+	}
+
+	resp := volumeStatsResponse(ctx, volPath, healthy, message)
+	if healthy && fs.IsQuotaEnabled {
+		if err := s.applyTreeQuotaStats(systemID, fs, resp); err != nil {
+			log.Warnf("could not get tree quota for filesystem: %s: %v", fs.Name, err)
+		}
+	}
+	return resp, nil
+}
+
+// applyTreeQuotaStats reports the byte usage of an NFS volume against its tree quota, which can be
+// smaller than the filesystem itself, and marks the volume abnormal when a quota limit is exceeded.
+func (s *service) applyTreeQuotaStats(systemID string, fs *siotypes.FileSystem, resp *csi.NodeGetVolumeStatsResponse) error {
+	system, err := s.adminClients[systemID].FindSystem(systemID, "", "")
+	if err != nil {
+		return err
+	}
+
+	treeQuota, err := system.GetTreeQuotaByFSID(fs.ID)
+	if err != nil {
+		return err
+	}
+
+	if treeQuota.HardLimit > 0 {
+		for _, usage := range resp.Usage {
+			if usage.Unit == csi.VolumeUsage_BYTES {
+				usage.Total = int64(treeQuota.HardLimit)
+				usage.Used = int64(treeQuota.SizeUsed)
+				usage.Available = max(int64(treeQuota.HardLimit-treeQuota.SizeUsed), 0)
+			}
+		}
+	}
+
+	if message := treeQuotaCondition(treeQuota); message != "" {
+		resp.VolumeCondition = &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  message,
+		}
+	}
+	return nil
+}
+
+// statWithTimeout stats path, giving up after timeout. A hung NFS server would otherwise
+// block the caller indefinitely, so the stat is run in its own goroutine.
+func statWithTimeout(path string, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := os.Stat(path)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("stat timed out after %v", timeout)
+	}
 }
 
 func (s *service) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
@@ -1194,7 +1423,8 @@ func (s *service) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolum
 	if err != nil {
 		return nil, status.Error(codes.NotFound, "Could not stat volume path: "+volumePath)
 	}
-	if s.useSDC && !volumePathInfo.Mode().IsDir() {
+	if !volumePathInfo.Mode().IsDir() && s.blockProtocol(s.getSystemIDFromCsiVolumeID(req.GetVolumeId())) == SDC &&
+		luksDevicePath(req.GetVolumeId()) == "" {
 		log.Infof("Volume path %s is not a directory- assuming a raw block device mount", volumePath)
 		return &csi.NodeExpandVolumeResponse{}, nil
 	}
@@ -1235,6 +1465,10 @@ func (s *service) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolum
 		return nil, err
 	}
 
+	if luksDevicePath(csiVolID) != "" {
+		return s.expandLUKSVolume(ctx, req, systemID)
+	}
+
 	vol, err := s.getVolByID(volumeID, systemID)
 	if err != nil {
 		return nil, status.Errorf(codes.Unavailable,
@@ -1242,7 +1476,7 @@ func (s *service) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolum
 	}
 	volname := vol.Name
 
-	if s.useNVME {
+	if s.isNVMeSystem(systemID) {
 
 		nguid, err := buildNGUID(volumeID, systemID)
 		log.Infof("printing nguidddd %s", nguid)
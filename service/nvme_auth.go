@@ -0,0 +1,346 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dell/gonvme"
+)
+
+const (
+	// prefixes of the DH-HMAC-CHAP secret and TLS PSK interchange formats
+	nvmeDHCHAPKeyPrefix = "DHHC-1:"
+	nvmeTLSKeyPrefix    = "NVMeTLSkey-1:"
+
+	nvmeTCPPort = "4420"
+)
+
+// nvme-cli reads the keys and host interface of the controllers it discovers and connects from its
+// JSON config, which is how they reach the nvme commands run by gonvme and gobrick. The driver only
+// owns the ports of the arrays it connects to in that file, everything else in it is kept as is.
+// Variables so tests can point them to a temporary directory.
+var (
+	nvmeConfigFile = "/etc/nvme/config.json"
+	nvmeClassDir   = "/sys/class/nvme"
+)
+
+// nvmeAuthConfig keeps the NVMe/TCP ports connected with authentication or bound to a host
+// interface, to render the nvme-cli config
+type nvmeAuthConfig struct {
+	sync.Mutex
+	ports map[string]nvmeAuthPort // by portal IP
+}
+
+type nvmeAuthPort struct {
+	systemID  string
+	targetNqn string
+	traddr    string
+	hostIface string
+}
+
+// nvme-cli JSON config, see libnvme config-schema.json
+type nvmeConfigHost struct {
+	HostNqn    string                `json:"hostnqn"`
+	Subsystems []nvmeConfigSubsystem `json:"subsystems"`
+}
+
+type nvmeConfigSubsystem struct {
+	Nqn   string           `json:"nqn"`
+	Ports []nvmeConfigPort `json:"ports"`
+}
+
+type nvmeConfigPort struct {
+	Transport     string `json:"transport"`
+	Traddr        string `json:"traddr"`
+	Trsvcid       string `json:"trsvcid"`
+	DHCHAPKey     string `json:"dhchap_key,omitempty"`
+	DHCHAPCtrlKey string `json:"dhchap_ctrl_key,omitempty"`
+	TLS           bool   `json:"tls,omitempty"`
+	TLSKey        string `json:"tls_key,omitempty"`
+	HostIface     string `json:"host_iface,omitempty"`
+}
+
+// hasNVMeAuth returns true when the array requires authenticated NVMe/TCP sessions
+func (array *ArrayConnectionData) hasNVMeAuth() bool {
+	return array.NVMeHostKey != "" || array.NVMeTLSKey != ""
+}
+
+// warnNVMeHostAuth reminds that the DH-HMAC-CHAP keys of an array requiring authentication must be
+// set on the NVMe host of the node on the system: the driver only hands them to nvme-cli.
+func (s *service) warnNVMeHostAuth(systemID, hostName string) {
+	array := s.opts.arrays[systemID]
+	if array == nil || array.NVMeHostKey == "" {
+		return
+	}
+	log.Warnf("NVMe/TCP sessions to system %s authenticate with DH-HMAC-CHAP, the driver does not set the keys on the system: "+
+		"NVMe host %s must be given them on the system, or its connections are refused", systemID, hostName)
+}
+
+// validateNVMeAuth checks the format of the NVMe/TCP keys of the array
+func (array *ArrayConnectionData) validateNVMeAuth() error {
+	if array.NVMeHostKey != "" && !strings.HasPrefix(array.NVMeHostKey, nvmeDHCHAPKeyPrefix) {
+		return fmt.Errorf("nvmeHostKey must be a DH-HMAC-CHAP secret starting with %s", nvmeDHCHAPKeyPrefix)
+	}
+	if array.NVMeControllerKey != "" {
+		if array.NVMeHostKey == "" {
+			return fmt.Errorf("nvmeControllerKey requires nvmeHostKey")
+		}
+		if !strings.HasPrefix(array.NVMeControllerKey, nvmeDHCHAPKeyPrefix) {
+			return fmt.Errorf("nvmeControllerKey must be a DH-HMAC-CHAP secret starting with %s", nvmeDHCHAPKeyPrefix)
+		}
+	}
+	if array.NVMeTLSKey != "" && !strings.HasPrefix(array.NVMeTLSKey, nvmeTLSKeyPrefix) {
+		return fmt.Errorf("nvmeTLSKey must be a TLS PSK starting with %s", nvmeTLSKeyPrefix)
+	}
+	return nil
+}
+
+// configureNVMePorts adds the discovered targets of an array requiring authentication, or bound to
+// a host interface by the portal filters, to the nvme-cli config before they are connected
+func (s *service) configureNVMePorts(systemID string, targets []gonvme.NVMeTarget) error {
+	array := s.opts.arrays[systemID]
+	hostIface := s.getNVMePortalFilter(systemID).hostIface
+	if array == nil || (!array.hasNVMeAuth() && hostIface == "") {
+		return nil
+	}
+	s.nvmeAuth.Lock()
+	defer s.nvmeAuth.Unlock()
+	if s.nvmeAuth.ports == nil {
+		s.nvmeAuth.ports = make(map[string]nvmeAuthPort)
+	}
+	for _, target := range targets {
+		s.nvmeAuth.ports[target.Portal] = nvmeAuthPort{systemID: systemID, targetNqn: target.TargetNqn, traddr: target.Portal, hostIface: hostIface}
+	}
+	return s.writeNVMeConfig()
+}
+
+// writeNVMeConfig updates the nvme-cli config with the current keys of every array. Must be
+// called with the nvmeAuth lock held.
+func (s *service) writeNVMeConfig() error {
+	if len(s.nvmeAuth.ports) == 0 {
+		return nil
+	}
+	initiators, err := s.nvmeLib.GetInitiators("")
+	if err != nil || len(initiators) == 0 {
+		return fmt.Errorf("could not get NVMe host NQN: %v", err)
+	}
+
+	subsystems := make(map[string]*nvmeConfigSubsystem)
+	for _, port := range s.nvmeAuth.ports {
+		array := s.opts.arrays[port.systemID]
+		if array == nil || (!array.hasNVMeAuth() && port.hostIface == "") {
+			continue
+		}
+		subsystem := subsystems[port.targetNqn]
+		if subsystem == nil {
+			subsystem = &nvmeConfigSubsystem{Nqn: port.targetNqn}
+			subsystems[port.targetNqn] = subsystem
+		}
+		subsystem.Ports = append(subsystem.Ports, nvmeConfigPort{
+			Transport:     string(gonvme.NVMETransportNameTCP),
+			Traddr:        port.traddr,
+			Trsvcid:       nvmeTCPPort,
+			DHCHAPKey:     array.NVMeHostKey,
+			DHCHAPCtrlKey: array.NVMeControllerKey,
+			TLS:           array.NVMeTLSKey != "",
+			TLSKey:        array.NVMeTLSKey,
+			HostIface:     port.hostIface,
+		})
+	}
+
+	host := nvmeConfigHost{HostNqn: initiators[0], Subsystems: make([]nvmeConfigSubsystem, 0, len(subsystems))}
+	for _, subsystem := range subsystems {
+		sort.Slice(subsystem.Ports, func(i, j int) bool { return subsystem.Ports[i].Traddr < subsystem.Ports[j].Traddr })
+		host.Subsystems = append(host.Subsystems, *subsystem)
+	}
+	sort.Slice(host.Subsystems, func(i, j int) bool { return host.Subsystems[i].Nqn < host.Subsystems[j].Nqn })
+
+	configFile := filepath.Join(s.opts.NodeChrootPath, nvmeConfigFile)
+	existing, err := os.ReadFile(filepath.Clean(configFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	ownPorts := make(map[string]bool, len(s.nvmeAuth.ports))
+	for traddr := range s.nvmeAuth.ports {
+		ownPorts[traddr] = true
+	}
+	data, err := mergeNVMeConfig(existing, host, ownPorts)
+	if err != nil {
+		return fmt.Errorf("could not update %s: %w", configFile, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+		return err
+	}
+	tmp := configFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, configFile)
+}
+
+// mergeNVMeConfig returns the nvme-cli config with the TCP ports of ownPorts replaced by the ports
+// of host. The other hosts, subsystems and ports, and the fields the driver does not know, are kept.
+func mergeNVMeConfig(existing []byte, host nvmeConfigHost, ownPorts map[string]bool) ([]byte, error) {
+	var hosts []map[string]interface{}
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if err := json.Unmarshal(existing, &hosts); err != nil {
+			return nil, err
+		}
+	}
+
+	var config map[string]interface{}
+	for _, h := range hosts {
+		if h["hostnqn"] == host.HostNqn {
+			config = h
+			break
+		}
+	}
+	if config == nil {
+		config = map[string]interface{}{"hostnqn": host.HostNqn}
+		hosts = append(hosts, config)
+	}
+
+	// remove the ports of the driver, dropping the subsystems left without ports
+	subsystems := make([]interface{}, 0)
+	existingSubsystems, _ := config["subsystems"].([]interface{})
+	for _, item := range existingSubsystems {
+		subsystem, ok := item.(map[string]interface{})
+		if !ok {
+			subsystems = append(subsystems, item)
+			continue
+		}
+		existingPorts, _ := subsystem["ports"].([]interface{})
+		ports := make([]interface{}, 0, len(existingPorts))
+		for _, portItem := range existingPorts {
+			port, ok := portItem.(map[string]interface{})
+			if ok && port["transport"] == string(gonvme.NVMETransportNameTCP) && ownPorts[fmt.Sprint(port["traddr"])] {
+				continue
+			}
+			ports = append(ports, portItem)
+		}
+		if len(ports) == 0 && len(existingPorts) > 0 {
+			continue
+		}
+		subsystem["ports"] = ports
+		subsystems = append(subsystems, subsystem)
+	}
+
+	// add the current ports of the driver
+	for _, own := range host.Subsystems {
+		var subsystem map[string]interface{}
+		for _, item := range subsystems {
+			if candidate, ok := item.(map[string]interface{}); ok && candidate["nqn"] == own.Nqn {
+				subsystem = candidate
+				break
+			}
+		}
+		if subsystem == nil {
+			subsystem = map[string]interface{}{"nqn": own.Nqn, "ports": []interface{}{}}
+			subsystems = append(subsystems, subsystem)
+		}
+		ports, _ := subsystem["ports"].([]interface{})
+		for _, ownPort := range own.Ports {
+			port, err := toJSONObject(ownPort)
+			if err != nil {
+				return nil, err
+			}
+			ports = append(ports, port)
+		}
+		subsystem["ports"] = ports
+	}
+	config["subsystems"] = subsystems
+
+	return json.MarshalIndent(hosts, "", "  ")
+}
+
+// toJSONObject returns the JSON object of a value, to merge it with JSON of unknown fields
+func toJSONObject(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	object := make(map[string]interface{})
+	return object, json.Unmarshal(data, &object)
+}
+
+// rotateNVMeKeys applies the keys of a reloaded array secret. The nvme-cli config is rewritten for
+// new connections, and the DH-HMAC-CHAP secrets of the connected controllers are replaced, which
+// makes the kernel authenticate them again. A new TLS PSK is only used by new connections.
+func (s *service) rotateNVMeKeys() {
+	if !s.useNVME {
+		return
+	}
+	s.nvmeAuth.Lock()
+	defer s.nvmeAuth.Unlock()
+	if err := s.writeNVMeConfig(); err != nil {
+		log.Errorf("could not update NVMe keys: %s", err.Error())
+	}
+
+	controllers, err := os.ReadDir(nvmeClassDir)
+	if err != nil {
+		log.Warnf("could not list NVMe controllers: %s", err.Error())
+		return
+	}
+	for _, controller := range controllers {
+		controllerDir := filepath.Join(nvmeClassDir, controller.Name())
+		port, ok := s.nvmeAuth.ports[nvmeControllerTraddr(controllerDir)]
+		if !ok {
+			continue
+		}
+		array := s.opts.arrays[port.systemID]
+		if array == nil || array.NVMeHostKey == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(controllerDir, "dhchap_secret"), []byte(array.NVMeHostKey), 0o600); err != nil {
+			log.Errorf("could not rotate the host key of NVMe controller %s: %s", controller.Name(), err.Error())
+			continue
+		}
+		if array.NVMeControllerKey != "" {
+			if err := os.WriteFile(filepath.Join(controllerDir, "dhchap_ctrl_secret"), []byte(array.NVMeControllerKey), 0o600); err != nil {
+				log.Errorf("could not rotate the controller key of NVMe controller %s: %s", controller.Name(), err.Error())
+				continue
+			}
+		}
+		log.Infof("rotated the DH-HMAC-CHAP keys of NVMe controller %s of array %s", controller.Name(), port.systemID)
+	}
+}
+
+// nvmeControllerTraddr returns the target address of an NVMe/TCP controller, e.g. from
+// traddr=10.0.0.1,trsvcid=4420,src_addr=10.0.0.2
+func nvmeControllerTraddr(controllerDir string) string {
+	transport, err := os.ReadFile(filepath.Join(controllerDir, "transport"))
+	if err != nil || strings.TrimSpace(string(transport)) != string(gonvme.NVMETransportNameTCP) {
+		return ""
+	}
+	address, err := os.ReadFile(filepath.Join(controllerDir, "address"))
+	if err != nil {
+		return ""
+	}
+	for _, field := range strings.Split(strings.TrimSpace(string(address)), ",") {
+		if traddr, ok := strings.CutPrefix(field, "traddr="); ok {
+			return traddr
+		}
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dell/gonvme"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testHostKey = "DHHC-1:00:ia6zGodOr4SEG0Zzaw398rpY0wqipUWj4jWjUh4HWUz6aQ2n:"
+	testCtrlKey = "DHHC-1:00:S1dPnJgD0cf5xE7ctGbxnRQ8SYr5qYZaeT2XnkhYm5LqZc9S:"
+	testTLSKey  = "NVMeTLSkey-1:01:VRLbtnN9AQb2WXW3c9+wEf/DRLz0QuLdbYvEhwtdWwNf9LrZ:"
+)
+
+func TestValidateNVMeAuth(t *testing.T) {
+	assert.NoError(t, (&ArrayConnectionData{}).validateNVMeAuth())
+	assert.NoError(t, (&ArrayConnectionData{NVMeHostKey: testHostKey, NVMeControllerKey: testCtrlKey, NVMeTLSKey: testTLSKey}).validateNVMeAuth())
+	assert.NoError(t, (&ArrayConnectionData{NVMeTLSKey: testTLSKey}).validateNVMeAuth())
+	assert.Error(t, (&ArrayConnectionData{NVMeHostKey: "secret"}).validateNVMeAuth())
+	assert.Error(t, (&ArrayConnectionData{NVMeControllerKey: testCtrlKey}).validateNVMeAuth())
+	assert.Error(t, (&ArrayConnectionData{NVMeHostKey: testHostKey, NVMeControllerKey: "secret"}).validateNVMeAuth())
+	assert.Error(t, (&ArrayConnectionData{NVMeTLSKey: testHostKey}).validateNVMeAuth())
+}
+
+func TestConfigureNVMeAuth(t *testing.T) {
+	s := &service{
+		nvmeLib: gonvme.NewMockNVMe(nil),
+		opts: Opts{
+			NodeChrootPath: t.TempDir(),
+			arrays: map[string]*ArrayConnectionData{
+				"sys1": {SystemID: "sys1", NVMeHostKey: testHostKey, NVMeControllerKey: testCtrlKey},
+				"sys2": {SystemID: "sys2"},
+			},
+		},
+	}
+	configFile := filepath.Join(s.opts.NodeChrootPath, nvmeConfigFile)
+
+	// arrays without keys leave the nvme-cli config alone
+	assert.NoError(t, s.configureNVMePorts("sys2", []gonvme.NVMeTarget{{Portal: "10.0.1.1", TargetNqn: "nqn.sys2"}}))
+	_, err := os.Stat(configFile)
+	assert.True(t, os.IsNotExist(err))
+
+	targets := []gonvme.NVMeTarget{
+		{Portal: "10.0.0.2", TargetNqn: "nqn.sys1"},
+		{Portal: "10.0.0.1", TargetNqn: "nqn.sys1"},
+	}
+	assert.NoError(t, s.configureNVMePorts("sys1", targets))
+
+	data, err := os.ReadFile(configFile)
+	assert.NoError(t, err)
+	var hosts []nvmeConfigHost
+	assert.NoError(t, json.Unmarshal(data, &hosts))
+	assert.Len(t, hosts, 1)
+	assert.Equal(t, "nqn.1988-11.com.dell.mock:01:0000000000000", hosts[0].HostNqn)
+	assert.Equal(t, []nvmeConfigSubsystem{{
+		Nqn: "nqn.sys1",
+		Ports: []nvmeConfigPort{
+			{Transport: "tcp", Traddr: "10.0.0.1", Trsvcid: "4420", DHCHAPKey: testHostKey, DHCHAPCtrlKey: testCtrlKey},
+			{Transport: "tcp", Traddr: "10.0.0.2", Trsvcid: "4420", DHCHAPKey: testHostKey, DHCHAPCtrlKey: testCtrlKey},
+		},
+	}}, hosts[0].Subsystems)
+}
+
+func TestMergeNVMeConfig(t *testing.T) {
+	existing := []byte(`[
+  {"hostnqn": "nqn.other", "subsystems": [{"nqn": "nqn.other.sys", "ports": [{"transport": "tcp", "traddr": "10.0.0.1"}]}]},
+  {"hostnqn": "nqn.host", "hostid": "id1", "subsystems": [
+    {"nqn": "nqn.sys1", "ports": [
+      {"transport": "tcp", "traddr": "10.0.0.1", "dhchap_key": "old"},
+      {"transport": "tcp", "traddr": "10.0.0.9", "dhchap_key": "admin"}
+    ]},
+    {"nqn": "nqn.sys3", "ports": [{"transport": "tcp", "traddr": "10.0.0.2", "dhchap_key": "old"}]}
+  ]}
+]`)
+	host := nvmeConfigHost{HostNqn: "nqn.host", Subsystems: []nvmeConfigSubsystem{{
+		Nqn:   "nqn.sys1",
+		Ports: []nvmeConfigPort{{Transport: "tcp", Traddr: "10.0.0.1", Trsvcid: "4420", DHCHAPKey: testHostKey}},
+	}}}
+
+	data, err := mergeNVMeConfig(existing, host, map[string]bool{"10.0.0.1": true, "10.0.0.2": true})
+	assert.NoError(t, err)
+	var merged []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &merged))
+	assert.Len(t, merged, 2)
+
+	// other hosts and unknown fields are kept
+	assert.Equal(t, "10.0.0.1", merged[0]["subsystems"].([]interface{})[0].(map[string]interface{})["ports"].([]interface{})[0].(map[string]interface{})["traddr"])
+	assert.Equal(t, "id1", merged[1]["hostid"])
+
+	var hosts []nvmeConfigHost
+	assert.NoError(t, json.Unmarshal(data, &hosts))
+	assert.Equal(t, []nvmeConfigSubsystem{{
+		Nqn: "nqn.sys1",
+		Ports: []nvmeConfigPort{
+			{Transport: "tcp", Traddr: "10.0.0.9", DHCHAPKey: "admin"},
+			{Transport: "tcp", Traddr: "10.0.0.1", Trsvcid: "4420", DHCHAPKey: testHostKey},
+		},
+	}}, hosts[1].Subsystems)
+
+	_, err = mergeNVMeConfig([]byte("{"), host, nil)
+	assert.Error(t, err)
+}
+
+func TestRotateNVMeKeys(t *testing.T) {
+	classDir := t.TempDir()
+	defer func(dir string) { nvmeClassDir = dir }(nvmeClassDir)
+	nvmeClassDir = classDir
+
+	for name, address := range map[string]string{
+		"nvme0": "traddr=10.0.0.1,trsvcid=4420,src_addr=10.0.0.100",
+		"nvme1": "traddr=10.0.9.9,trsvcid=4420,src_addr=10.0.0.100",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(classDir, name), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(classDir, name, "transport"), []byte("tcp\n"), 0o600))
+		assert.NoError(t, os.WriteFile(filepath.Join(classDir, name, "address"), []byte(address+"\n"), 0o600))
+	}
+	assert.Equal(t, "10.0.0.1", nvmeControllerTraddr(filepath.Join(classDir, "nvme0")))
+
+	s := &service{
+		useNVME: true,
+		nvmeLib: gonvme.NewMockNVMe(nil),
+		opts: Opts{
+			NodeChrootPath: t.TempDir(),
+			arrays: map[string]*ArrayConnectionData{
+				"sys1": {SystemID: "sys1", NVMeHostKey: testHostKey},
+			},
+		},
+	}
+	assert.NoError(t, s.configureNVMePorts("sys1", []gonvme.NVMeTarget{{Portal: "10.0.0.1", TargetNqn: "nqn.sys1"}}))
+
+	// the reloaded secret has new keys
+	s.opts.arrays["sys1"] = &ArrayConnectionData{SystemID: "sys1", NVMeHostKey: testCtrlKey, NVMeControllerKey: testHostKey}
+	s.rotateNVMeKeys()
+
+	secret, err := os.ReadFile(filepath.Join(classDir, "nvme0", "dhchap_secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, testCtrlKey, string(secret))
+	secret, err = os.ReadFile(filepath.Join(classDir, "nvme0", "dhchap_ctrl_secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, testHostKey, string(secret))
+	_, err = os.Stat(filepath.Join(classDir, "nvme1", "dhchap_secret"))
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := os.ReadFile(filepath.Join(s.opts.NodeChrootPath, nvmeConfigFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), testCtrlKey)
+}
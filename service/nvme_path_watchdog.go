@@ -0,0 +1,358 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dell/gonvme"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultNVMePathCheckInterval = time.Minute
+	maxNVMeReconnectBackoff      = 15 * time.Minute
+
+	// NodeMetrics is the route serving the NVMe/TCP path counts and trims of the node volumes
+	NodeMetrics = "/metrics"
+
+	nvmeControllerLive = "live"
+	nvmeEUIPrefix      = "nvme-eui."
+)
+
+// locations of the NVMe namespaces, variables so tests can point them to a temporary directory
+var (
+	nvmeSysBlockDir = "/sys/block"
+	nvmeDiskByIDDir = "/dev/disk/by-id"
+)
+
+// nvmeVolumePaths are the NVMe/TCP paths of a volume connected to the node
+type nvmeVolumePaths struct {
+	Device   string
+	Live     int
+	Expected int
+}
+
+// degraded returns true when the volume is reached through fewer paths than the array has portals
+func (p nvmeVolumePaths) degraded() bool {
+	return p.Live < p.Expected
+}
+
+// nvmeReconnectBackoff delays the next reconnect attempt to a portal that failed to connect
+type nvmeReconnectBackoff struct {
+	failures int
+	next     time.Time
+}
+
+// startNVMePathWatchdog periodically compares the NVMe/TCP controllers of the node against the
+// targets of each array and reconnects the lost ones. Connections are otherwise only made at
+// startup and when staging volumes, leaving volumes on fewer paths after a target portal reboot.
+func (s *service) startNVMePathWatchdog(ctx context.Context) {
+	interval := s.opts.NVMePathCheckInterval
+	if interval <= 0 {
+		log.Info("NVMe/TCP path check is disabled")
+		return
+	}
+	log.Infof("checking NVMe/TCP paths every %s", interval)
+
+	backoff := make(map[string]*nvmeReconnectBackoff)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkNVMePaths(backoff, time.Now())
+		}
+	}
+}
+
+// checkNVMePaths reconnects the portals of each array the node has no controller for and
+// refreshes the path counts of the node volumes
+func (s *service) checkNVMePaths(backoff map[string]*nvmeReconnectBackoff, now time.Time) {
+	sessions, err := s.nvmeLib.GetSessions()
+	if err != nil {
+		log.Warnf("could not get NVMe sessions: %s", err.Error())
+		return
+	}
+	connected := make(map[string]bool)
+	for _, session := range sessions {
+		if session.NVMETransportName == gonvme.NVMETransportNameTCP {
+			connected[session.Portal] = true
+		}
+	}
+
+	for _, array := range s.opts.arrays {
+		system := s.systems[array.SystemID]
+		if system == nil || !s.isNVMeSystem(array.SystemID) {
+			continue
+		}
+		portals, err := getNVMETCPTargetsInfoFromStorage(system)
+		if err != nil {
+			log.Warnf("could not get NVMe/TCP targets of array %s: %s", array.SystemID, err.Error())
+			continue
+		}
+		portals = s.getNVMePortalFilter(array.SystemID).filterPortals(portals)
+		s.nvmeExpectedPaths.Store(array.SystemID, len(portals))
+
+		for _, portal := range portals {
+			if connected[portal] {
+				delete(backoff, portal)
+				continue
+			}
+			b := backoff[portal]
+			if b != nil && now.Before(b.next) {
+				continue
+			}
+			log.Warnf("no NVMe/TCP controller for portal %s of array %s, reconnecting", portal, array.SystemID)
+			if err := s.reconnectNVMePortal(array.SystemID, portal); err != nil {
+				if b == nil {
+					b = &nvmeReconnectBackoff{}
+					backoff[portal] = b
+				}
+				b.failures++
+				b.next = now.Add(reconnectDelay(s.opts.NVMePathCheckInterval, b.failures))
+				log.Errorf("reconnecting NVMe/TCP portal %s failed %d times, next attempt at %s: %s",
+					portal, b.failures, b.next.Format(time.RFC3339), err.Error())
+				continue
+			}
+			delete(backoff, portal)
+			log.Infof("reconnected NVMe/TCP portal %s of array %s", portal, array.SystemID)
+		}
+	}
+
+	s.updateNVMeVolumePaths()
+}
+
+// reconnectDelay doubles the check interval for every failed attempt, up to maxNVMeReconnectBackoff
+func reconnectDelay(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 1; i < failures && delay < maxNVMeReconnectBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxNVMeReconnectBackoff {
+		delay = maxNVMeReconnectBackoff
+	}
+	return delay
+}
+
+// reconnectNVMePortal discovers the targets of the portal and connects to them
+func (s *service) reconnectNVMePortal(systemID, portal string) error {
+	ip, _, err := net.SplitHostPort(portal)
+	if err != nil {
+		return err
+	}
+	targets, err := s.nvmeLib.DiscoverNVMeTCPTargets(ip, false)
+	if err != nil {
+		return err
+	}
+	portalTargets := make([]gonvme.NVMeTarget, 0, len(targets))
+	for _, target := range targets {
+		if target.Portal == ip {
+			portalTargets = append(portalTargets, target)
+		}
+	}
+	if err := s.configureNVMePorts(systemID, portalTargets); err != nil {
+		return err
+	}
+	connected := false
+	for _, target := range portalTargets {
+		if err := s.nvmeLib.NVMeTCPConnect(target, false); err != nil {
+			return err
+		}
+		connected = true
+	}
+	if !connected {
+		return fmt.Errorf("portal %s returned no target for itself", ip)
+	}
+	return nil
+}
+
+// updateNVMeVolumePaths counts the live paths of every PowerFlex NVMe namespace on the node
+func (s *service) updateNVMeVolumePaths() {
+	entries, err := os.ReadDir(nvmeDiskByIDDir)
+	if err != nil {
+		log.Warnf("could not list NVMe namespaces: %s", err.Error())
+		return
+	}
+	current := make(map[string]bool)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), nvmeEUIPrefix) {
+			continue
+		}
+		csiVolID := s.getCSIVolumeIDFromNGUID(strings.TrimPrefix(entry.Name(), nvmeEUIPrefix))
+		if csiVolID == "" {
+			continue
+		}
+		paths, err := s.getNVMeVolumePaths(filepath.Join(nvmeDiskByIDDir, entry.Name()), s.getSystemIDFromCsiVolumeID(csiVolID))
+		if err != nil {
+			log.Warnf("could not count NVMe/TCP paths of volume %s: %s", csiVolID, err.Error())
+			continue
+		}
+		s.nvmeVolumePaths.Store(csiVolID, paths)
+		current[csiVolID] = true
+	}
+	s.nvmeVolumePaths.Range(func(key, _ interface{}) bool {
+		if !current[key.(string)] {
+			s.nvmeVolumePaths.Delete(key)
+		}
+		return true
+	})
+}
+
+// getCSIVolumeIDFromNGUID returns the CSI volume ID of a namespace of one of the arrays, see buildNGUID
+func (s *service) getCSIVolumeIDFromNGUID(nguid string) string {
+	nguid = normalize(nguid)
+	if len(nguid) != nguidLen || nguid[volumeIDLen:volumeIDLen+len(oui)] != oui {
+		return ""
+	}
+	for _, array := range s.opts.arrays {
+		systemID := normalize(array.SystemID)
+		if len(systemID) >= clusterLSBLen && strings.HasSuffix(nguid, systemID[len(systemID)-clusterLSBLen:]) {
+			return array.SystemID + "-" + nguid[:volumeIDLen]
+		}
+	}
+	return ""
+}
+
+// getNVMeVolumePaths counts the live controllers the namespace is reached through. With native
+// NVMe multipathing every controller has a path under the multipath directory of the namespace.
+func (s *service) getNVMeVolumePaths(devicePath, systemID string) (nvmeVolumePaths, error) {
+	device, err := filepath.EvalSymlinks(devicePath)
+	if err != nil {
+		return nvmeVolumePaths{}, err
+	}
+	paths := nvmeVolumePaths{Device: filepath.Base(device)}
+	blockDir := filepath.Join(nvmeSysBlockDir, paths.Device)
+
+	total := 0
+	if pathEntries, err := os.ReadDir(filepath.Join(blockDir, "multipath")); err == nil && len(pathEntries) > 0 {
+		for _, entry := range pathEntries {
+			total++
+			if nvmeControllerState(filepath.Join(blockDir, "multipath", entry.Name(), "device")) == nvmeControllerLive {
+				paths.Live++
+			}
+		}
+	} else {
+		total = 1
+		if nvmeControllerState(filepath.Join(blockDir, "device")) == nvmeControllerLive {
+			paths.Live++
+		}
+	}
+
+	paths.Expected = total
+	if expected, ok := s.nvmeExpectedPaths.Load(systemID); ok && expected.(int) > total {
+		paths.Expected = expected.(int)
+	}
+	return paths, nil
+}
+
+// nvmeControllerState returns the state of the NVMe controller in its sysfs directory
+func nvmeControllerState(controllerDir string) string {
+	state, err := os.ReadFile(filepath.Join(controllerDir, "state"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(state))
+}
+
+// checkNVMeVolumePaths returns the NVMe/TCP paths of the volume, and false when it is not
+// connected through NVMe/TCP
+func (s *service) checkNVMeVolumePaths(volID, systemID string) (nvmeVolumePaths, bool) {
+	if !s.isNVMeSystem(systemID) {
+		return nvmeVolumePaths{}, false
+	}
+	nguid, err := buildNGUID(volID, systemID)
+	if err != nil {
+		return nvmeVolumePaths{}, false
+	}
+	devicePath := filepath.Join(nvmeDiskByIDDir, nvmeEUIPrefix+nguid)
+	if _, err := os.Stat(devicePath); err != nil {
+		return nvmeVolumePaths{}, false
+	}
+	paths, err := s.getNVMeVolumePaths(devicePath, systemID)
+	if err != nil {
+		log.Warnf("could not count NVMe/TCP paths of volume %s: %s", volID, err.Error())
+		return nvmeVolumePaths{}, false
+	}
+	return paths, true
+}
+
+// nodeMetricsRouter serves the NVMe/TCP path counts and the trims of the node volumes
+func (s *service) nodeMetricsRouter() {
+	log.Infof("serving node metrics on port %s", s.opts.NodeMetricsPort)
+	router := mux.NewRouter()
+	router.HandleFunc(NodeMetrics, s.nodeMetrics).Methods("GET")
+	server := &http.Server{
+		Addr:         s.opts.NodeMetricsPort,
+		Handler:      router,
+		ReadTimeout:  Timeout,
+		WriteTimeout: Timeout,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		log.Errorf("unable to start http server to serve node metrics due to %s", err)
+	}
+}
+
+// nodeMetrics handler returns the live and expected NVMe/TCP paths and the last trim of each
+// volume in the Prometheus text format
+func (s *service) nodeMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if _, err := w.Write([]byte(s.formatNVMePathMetrics() + s.formatFstrimMetrics())); err != nil {
+		log.Errorf("unable to write response %s", err)
+	}
+}
+
+func (s *service) formatNVMePathMetrics() string {
+	volumes := make(map[string]nvmeVolumePaths)
+	ids := make([]string, 0)
+	s.nvmeVolumePaths.Range(func(key, value interface{}) bool {
+		volumes[key.(string)] = value.(nvmeVolumePaths)
+		ids = append(ids, key.(string))
+		return true
+	})
+	sort.Strings(ids)
+
+	var b strings.Builder
+	b.WriteString("# HELP powerflex_nvme_volume_paths_live Number of live NVMe/TCP paths of the volume\n")
+	b.WriteString("# TYPE powerflex_nvme_volume_paths_live gauge\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "powerflex_nvme_volume_paths_live{volume_id=%q,device=%q} %d\n", id, volumes[id].Device, volumes[id].Live)
+	}
+	b.WriteString("# HELP powerflex_nvme_volume_paths_expected Number of NVMe/TCP paths the volume should have\n")
+	b.WriteString("# TYPE powerflex_nvme_volume_paths_expected gauge\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "powerflex_nvme_volume_paths_expected{volume_id=%q,device=%q} %d\n", id, volumes[id].Device, volumes[id].Expected)
+	}
+	b.WriteString("# HELP powerflex_nvme_stage_repairs_total Number of NVMe staging paths repaired, by staging state\n")
+	b.WriteString("# TYPE powerflex_nvme_stage_repairs_total counter\n")
+	for _, stageStatus := range []StageStatus{StageDeletedLink, StageMpathMember, StageProbeError} {
+		var repairs int64
+		if counter, ok := s.nvmeStageRepairs.Load(stageStatus); ok {
+			repairs = counter.(*atomic.Int64).Load()
+		}
+		fmt.Fprintf(&b, "powerflex_nvme_stage_repairs_total{state=%q} %d\n", stageStatus, repairs)
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dell/gonvme"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNVMeNamespace creates the sysfs entries of a namespace reached through controllers in the given states
+func fakeNVMeNamespace(t *testing.T, sysBlock, byID, device, nguid string, states ...string) {
+	for i, state := range states {
+		controllerDir := filepath.Join(sysBlock, device, "multipath", device+"c"+string(rune('0'+i)), "device")
+		assert.NoError(t, os.MkdirAll(controllerDir, 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(controllerDir, "state"), []byte(state+"\n"), 0o600))
+	}
+	devicePath := filepath.Join(t.TempDir(), device)
+	assert.NoError(t, os.WriteFile(devicePath, nil, 0o600))
+	assert.NoError(t, os.Symlink(devicePath, filepath.Join(byID, nvmeEUIPrefix+nguid)))
+}
+
+func TestNVMeVolumePaths(t *testing.T) {
+	sysBlock := t.TempDir()
+	byID := t.TempDir()
+	defer func(sysBlockDir, diskByIDDir string) {
+		nvmeSysBlockDir = sysBlockDir
+		nvmeDiskByIDDir = diskByIDDir
+	}(nvmeSysBlockDir, nvmeDiskByIDDir)
+	nvmeSysBlockDir = sysBlock
+	nvmeDiskByIDDir = byID
+
+	systemID := "14dbbf5617523654"
+	s := &service{useNVME: true, opts: Opts{arrays: map[string]*ArrayConnectionData{systemID: {SystemID: systemID}}}}
+	s.nvmeExpectedPaths.Store(systemID, 3)
+
+	nguid1, err := buildNGUID("b13c7f4b00000001", systemID)
+	assert.NoError(t, err)
+	nguid2, err := buildNGUID("b13c7f4b00000002", systemID)
+	assert.NoError(t, err)
+	fakeNVMeNamespace(t, sysBlock, byID, "nvme0n1", nguid1, "live", "live", "live")
+	fakeNVMeNamespace(t, sysBlock, byID, "nvme0n2", nguid2, "live", "connecting")
+
+	assert.Equal(t, systemID+"-b13c7f4b00000001", s.getCSIVolumeIDFromNGUID(nguid1))
+	assert.Equal(t, "", s.getCSIVolumeIDFromNGUID("b13c7f4b00000001"+oui+"0000000000"))
+
+	paths, ok := s.checkNVMeVolumePaths("b13c7f4b00000001", systemID)
+	assert.True(t, ok)
+	assert.Equal(t, nvmeVolumePaths{Device: "nvme0n1", Live: 3, Expected: 3}, paths)
+	assert.False(t, paths.degraded())
+
+	paths, ok = s.checkNVMeVolumePaths("b13c7f4b00000002", systemID)
+	assert.True(t, ok)
+	assert.Equal(t, nvmeVolumePaths{Device: "nvme0n2", Live: 1, Expected: 3}, paths)
+	assert.True(t, paths.degraded())
+
+	_, ok = s.checkNVMeVolumePaths("b13c7f4b00000003", systemID)
+	assert.False(t, ok)
+
+	s.updateNVMeVolumePaths()
+	metrics := s.formatNVMePathMetrics()
+	assert.Contains(t, metrics, `powerflex_nvme_volume_paths_live{volume_id="`+systemID+`-b13c7f4b00000002",device="nvme0n2"} 1`)
+	assert.Contains(t, metrics, `powerflex_nvme_volume_paths_expected{volume_id="`+systemID+`-b13c7f4b00000002",device="nvme0n2"} 3`)
+	assert.Equal(t, 2, strings.Count(metrics, "powerflex_nvme_volume_paths_live{"))
+}
+
+func TestReconnectNVMePortal(t *testing.T) {
+	s := &service{nvmeLib: gonvme.NewMockNVMe(nil)}
+	assert.NoError(t, s.reconnectNVMePortal("sys1", "10.0.0.1:4420"))
+	assert.Error(t, s.reconnectNVMePortal("sys1", "10.0.0.1"))
+
+	gonvme.GONVMEMock.InduceDiscoveryError = true
+	defer func() { gonvme.GONVMEMock.InduceDiscoveryError = false }()
+	assert.Error(t, s.reconnectNVMePortal("sys1", "10.0.0.1:4420"))
+}
+
+func TestReconnectDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, reconnectDelay(time.Minute, 1))
+	assert.Equal(t, 4*time.Minute, reconnectDelay(time.Minute, 3))
+	assert.Equal(t, maxNVMeReconnectBackoff, reconnectDelay(time.Minute, 10))
+}
@@ -0,0 +1,186 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// NVMePortalFilter restricts the NVMe/TCP portals a node connects to, for the nodes reaching the
+// storage network on some interfaces only. A filter applies to the arrays, zone and nodes it
+// selects, empty selectors match everything. Filters are set with nvmePortalFilters in the driver
+// config params.
+type NVMePortalFilter struct {
+	// SystemID of the array the filter applies to
+	SystemID string `json:"systemID,omitempty"`
+	// Zone is the value of the zone label of the nodes the filter applies to
+	Zone string `json:"zone,omitempty"`
+	// NodeSelector is the labels of the nodes the filter applies to
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// CIDRs are the networks of the portals the nodes connect to
+	CIDRs []string `json:"cidrs,omitempty"`
+	// Interface is the host interface the NVMe/TCP sessions are bound to
+	Interface string `json:"interface,omitempty"`
+}
+
+// nvmePortalFilters keeps the filters of the driver config params and the node labels they select
+type nvmePortalFilters struct {
+	sync.Mutex
+	filters    []NVMePortalFilter
+	nodeLabels map[string]string
+}
+
+// nvmePortalFilter is the result of the filters matching the node for an array
+type nvmePortalFilter struct {
+	networks  []*net.IPNet
+	hostIface string
+}
+
+// allows returns true when the node may connect to the portal IP
+func (f nvmePortalFilter) allows(ip string) bool {
+	if len(f.networks) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	for _, network := range f.networks {
+		if addr != nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPortals returns the portals, ip:port, the node may connect to
+func (f nvmePortalFilter) filterPortals(portals []string) []string {
+	if len(f.networks) == 0 {
+		return portals
+	}
+	var allowed []string
+	for _, portal := range portals {
+		ip, _, err := net.SplitHostPort(portal)
+		if err != nil || !f.allows(ip) {
+			log.Debugf("NVMe/TCP portal %s is filtered out", portal)
+			continue
+		}
+		allowed = append(allowed, portal)
+	}
+	return allowed
+}
+
+// validateNVMePortalFilter checks the networks and interface of a filter
+func validateNVMePortalFilter(filter NVMePortalFilter) error {
+	if len(filter.CIDRs) == 0 && filter.Interface == "" {
+		return fmt.Errorf("NVMe portal filter for system %q zone %q has neither cidrs nor interface", filter.SystemID, filter.Zone)
+	}
+	for _, cidr := range filter.CIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("NVMe portal filter for system %q zone %q: %s", filter.SystemID, filter.Zone, err.Error())
+		}
+	}
+	return nil
+}
+
+// loadNVMePortalFilters reads the NVMe/TCP portal filters of the driver config params. Invalid
+// filters are ignored.
+func (s *service) loadNVMePortalFilters(configFile string) {
+	if configFile == "" {
+		return
+	}
+	data, err := os.ReadFile(filepath.Clean(configFile))
+	if err != nil {
+		log.Errorf("unable to read NVMe portal filters: %v", err)
+		return
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		log.Errorf("unable to parse NVMe portal filters: %v", err)
+		return
+	}
+
+	filters := make([]NVMePortalFilter, 0, len(config.NVMePortalFilters))
+	for _, filter := range config.NVMePortalFilters {
+		if err := validateNVMePortalFilter(filter); err != nil {
+			log.Errorf("ignoring invalid NVMe portal filter: %s", err.Error())
+			continue
+		}
+		filters = append(filters, filter)
+	}
+	if len(filters) > 0 {
+		log.Infof("NVMe portal filters: %+v", filters)
+	}
+
+	s.nvmePortalFilters.Lock()
+	defer s.nvmePortalFilters.Unlock()
+	s.nvmePortalFilters.filters = filters
+	// labels are read again for the new selectors
+	s.nvmePortalFilters.nodeLabels = nil
+}
+
+// getNVMePortalFilter returns the portal networks and host interface of the filters matching the
+// node for the array
+func (s *service) getNVMePortalFilter(systemID string) nvmePortalFilter {
+	s.nvmePortalFilters.Lock()
+	defer s.nvmePortalFilters.Unlock()
+
+	var result nvmePortalFilter
+	for _, filter := range s.nvmePortalFilters.filters {
+		if filter.SystemID != "" && filter.SystemID != systemID {
+			continue
+		}
+		if (filter.Zone != "" || len(filter.NodeSelector) > 0) && !s.nodeMatchesNVMePortalFilter(filter) {
+			continue
+		}
+		for _, cidr := range filter.CIDRs {
+			if _, network, err := net.ParseCIDR(cidr); err == nil {
+				result.networks = append(result.networks, network)
+			}
+		}
+		if result.hostIface == "" {
+			result.hostIface = filter.Interface
+		}
+	}
+	return result
+}
+
+// nodeMatchesNVMePortalFilter returns true when the zone and labels of the node match the filter.
+// Must be called with the nvmePortalFilters lock held.
+func (s *service) nodeMatchesNVMePortalFilter(filter NVMePortalFilter) bool {
+	if s.nvmePortalFilters.nodeLabels == nil {
+		labels, err := GetNodeLabels(context.Background(), s)
+		if err != nil {
+			log.Errorf("unable to get node labels for NVMe portal filters: %s", err.Error())
+			return false
+		}
+		s.nvmePortalFilters.nodeLabels = labels
+	}
+	labels := s.nvmePortalFilters.nodeLabels
+	if filter.Zone != "" {
+		if zone, ok := labels[s.opts.zoneLabelKey]; s.opts.zoneLabelKey == "" || !ok || zone != filter.Zone {
+			return false
+		}
+	}
+	for key, value := range filter.NodeSelector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
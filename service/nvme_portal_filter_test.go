@@ -0,0 +1,99 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dell/gonvme"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testPortalFilters = `
+CSI_LOG_LEVEL: "INFO"
+nvmePortalFilters:
+  - systemID: sys1
+    cidrs: ["10.0.1.0/24"]
+  - zone: zoneA
+    cidrs: ["10.0.2.0/24"]
+    interface: ens1f0
+  - nodeSelector:
+      storage-vlan: "b"
+    cidrs: ["10.0.3.0/24"]
+  - systemID: sys2
+    cidrs: ["not-a-cidr"]
+`
+
+func TestNVMePortalFilter(t *testing.T) {
+	defer func() { K8sClientset = nil }()
+	K8sClientset = fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node1",
+			Labels: map[string]string{"zone.example.com": "zoneA", "storage-vlan": "a"},
+		},
+	})
+
+	configFile := filepath.Join(t.TempDir(), "driver-config-params.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte(testPortalFilters), 0o600))
+
+	s := &service{opts: Opts{KubeNodeName: "node1", zoneLabelKey: "zone.example.com"}}
+	s.loadNVMePortalFilters(configFile)
+	assert.Len(t, s.nvmePortalFilters.filters, 3)
+
+	// the array filter and the zone filter match, the node selector does not
+	filter := s.getNVMePortalFilter("sys1")
+	assert.Equal(t, "ens1f0", filter.hostIface)
+	assert.Equal(t, []string{"10.0.1.1:4420", "10.0.2.1:4420"},
+		filter.filterPortals([]string{"10.0.1.1:4420", "10.0.2.1:4420", "10.0.3.1:4420", "10.0.4.1:4420"}))
+
+	filter = s.getNVMePortalFilter("sys3")
+	assert.False(t, filter.allows("10.0.1.1"))
+	assert.True(t, filter.allows("10.0.2.1"))
+
+	// without filters every portal is used
+	portals := []string{"10.0.4.1:4420"}
+	assert.Equal(t, portals, (&service{}).getNVMePortalFilter("sys1").filterPortals(portals))
+}
+
+func TestValidateNVMePortalFilter(t *testing.T) {
+	assert.NoError(t, validateNVMePortalFilter(NVMePortalFilter{CIDRs: []string{"10.0.0.0/8", "fd00::/8"}}))
+	assert.NoError(t, validateNVMePortalFilter(NVMePortalFilter{Interface: "ens1f0"}))
+	assert.Error(t, validateNVMePortalFilter(NVMePortalFilter{SystemID: "sys1"}))
+	assert.Error(t, validateNVMePortalFilter(NVMePortalFilter{CIDRs: []string{"10.0.0.1"}}))
+}
+
+func TestConfigureNVMePortsHostIface(t *testing.T) {
+	s := &service{
+		nvmeLib: gonvme.NewMockNVMe(nil),
+		opts: Opts{
+			NodeChrootPath: t.TempDir(),
+			arrays:         map[string]*ArrayConnectionData{"sys1": {SystemID: "sys1"}},
+		},
+	}
+	s.nvmePortalFilters.filters = []NVMePortalFilter{{SystemID: "sys1", Interface: "ens1f0"}}
+
+	assert.NoError(t, s.configureNVMePorts("sys1", []gonvme.NVMeTarget{{Portal: "10.0.0.1", TargetNqn: "nqn.sys1"}}))
+
+	data, err := os.ReadFile(filepath.Join(s.opts.NodeChrootPath, nvmeConfigFile))
+	assert.NoError(t, err)
+	var hosts []nvmeConfigHost
+	assert.NoError(t, json.Unmarshal(data, &hosts))
+	assert.Equal(t, []nvmeConfigPort{{Transport: "tcp", Traddr: "10.0.0.1", Trsvcid: "4420", HostIface: "ens1f0"}}, hosts[0].Subsystems[0].Ports)
+}
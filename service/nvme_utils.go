@@ -70,6 +70,11 @@ func (s *service) discoverNVMeTargets(system *goscaleio.System) ([]gonvme.NVMeTa
 	if err != nil {
 		return nil, fmt.Errorf("failed to get targets from array: %w", err)
 	}
+	filter := s.getNVMePortalFilter(system.System.ID)
+	portals = filter.filterPortals(portals)
+	if len(portals) == 0 {
+		return nil, fmt.Errorf("no NVMe/TCP portal of array %s is allowed by the portal filters", system.System.ID)
+	}
 
 	discoveredTargets := make(map[string]gonvme.NVMeTarget)
 	for _, portal := range portals {
@@ -90,6 +95,9 @@ func (s *service) discoverNVMeTargets(system *goscaleio.System) ([]gonvme.NVMeTa
 			continue
 		}
 		for _, target := range targets {
+			if !filter.allows(target.Portal) {
+				continue
+			}
 			discoveredTargets[target.Portal] = target
 			s.nvmeTargetNqn[target.Portal] = target.TargetNqn
 		}
@@ -108,6 +116,9 @@ func (s *service) discoverNVMeTargets(system *goscaleio.System) ([]gonvme.NVMeTa
 }
 
 func (s *service) connectToNVMeTargets(system *goscaleio.System, targets []gonvme.NVMeTarget) error {
+	if err := s.configureNVMePorts(system.System.ID, targets); err != nil {
+		return fmt.Errorf("failed to configure NVMe ports for array %s: %w", system.System.ID, err)
+	}
 	connected := false
 	for _, t := range targets {
 		log.Infof("Connecting to NVMe target %v", t)
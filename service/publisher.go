@@ -38,7 +38,11 @@ type NVMePublisher struct {
 
 func (p *NVMePublisher) Publish(ctx context.Context, req *csi.ControllerPublishVolumeRequest, adminClient *goscaleio.Client, systemID, csiVolID string) (*csi.ControllerPublishVolumeResponse, error) {
 	log.Debugf("ControllerPublish - in NVMePublisher")
-	volumeContext := req.GetVolumeContext()
+	// limits given per GiB are computed for the current size of the volume
+	volumeContext, err := getEffectiveQoSContext(req.GetVolumeContext(), int64(p.vol.SizeInKb))
+	if err != nil {
+		return nil, err
+	}
 	nodeID := req.GetNodeId()
 	am := req.GetVolumeCapability().GetAccessMode()
 	vcs := []*csi.VolumeCapability{req.GetVolumeCapability()}
@@ -110,7 +114,11 @@ type SDCPublisher struct {
 
 func (p *SDCPublisher) Publish(ctx context.Context, req *csi.ControllerPublishVolumeRequest, adminClient *goscaleio.Client, systemID, csiVolID string) (*csi.ControllerPublishVolumeResponse, error) {
 	log.Debugf("ControllerPublish - in SDCPublisher")
-	volumeContext := req.GetVolumeContext()
+	// limits given per GiB are computed for the current size of the volume
+	volumeContext, err := getEffectiveQoSContext(req.GetVolumeContext(), int64(p.vol.SizeInKb))
+	if err != nil {
+		return nil, err
+	}
 	nodeID := req.GetNodeId()
 	am := req.GetVolumeCapability().GetAccessMode()
 	vcs := []*csi.VolumeCapability{req.GetVolumeCapability()}
@@ -0,0 +1,276 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"strconv"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/dell/csi-vxflexos/v2/k8sutils"
+	"github.com/dell/goscaleio"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// minIopsLimit is the lowest IOPS limit accepted by PowerFlex for a mapped volume
+	minIopsLimit = 11
+
+	// bandwidth limits are set in KBps but kept by the array in MBps
+	bandwidthLimitGranularity = 1024
+)
+
+// scaledQoSLimit is a QoS limit that scales with the size of the volume
+type scaledQoSLimit struct {
+	absoluteKey string
+	perGiBKey   string
+	minKey      string
+	maxKey      string
+}
+
+var scaledQoSLimits = []scaledQoSLimit{
+	{
+		absoluteKey: KeyIopsLimit,
+		perGiBKey:   KeyIopsLimitPerGiB,
+		minKey:      KeyMinIopsLimit,
+		maxKey:      KeyMaxIopsLimit,
+	},
+	{
+		absoluteKey: KeyBandwidthLimitInKbps,
+		perGiBKey:   KeyBandwidthLimitPerGiBInKbps,
+		minKey:      KeyMinBandwidthLimitInKbps,
+		maxKey:      KeyMaxBandwidthLimitInKbps,
+	},
+}
+
+// hasScaledQoS returns true when any of the QoS limits is given per GiB
+func hasScaledQoS(params map[string]string) bool {
+	for _, limit := range scaledQoSLimits {
+		if params[limit.perGiBKey] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateScaledQoSParameters checks the per GiB QoS parameters of the StorageClass. A limit
+// is either absolute or per GiB, and the floor and ceiling only apply to per GiB limits.
+func validateScaledQoSParameters(params map[string]string) error {
+	for _, limit := range scaledQoSLimits {
+		values := make(map[string]int64)
+		for _, key := range []string{limit.perGiBKey, limit.minKey, limit.maxKey} {
+			value, err := parseQoSParameter(params, key)
+			if err != nil {
+				return err
+			}
+			values[key] = value
+		}
+		if params[limit.perGiBKey] == "" {
+			if params[limit.minKey] != "" || params[limit.maxKey] != "" {
+				return status.Errorf(codes.InvalidArgument, "%s and %s require %s",
+					limit.minKey, limit.maxKey, limit.perGiBKey)
+			}
+			continue
+		}
+		if params[limit.absoluteKey] != "" {
+			return status.Errorf(codes.InvalidArgument, "%s and %s cannot both be set",
+				limit.absoluteKey, limit.perGiBKey)
+		}
+		if params[limit.minKey] != "" && params[limit.maxKey] != "" && values[limit.minKey] > values[limit.maxKey] {
+			return status.Errorf(codes.InvalidArgument, "%s: %d is greater than %s: %d",
+				limit.minKey, values[limit.minKey], limit.maxKey, values[limit.maxKey])
+		}
+	}
+	return nil
+}
+
+// parseQoSParameter returns the value of a QoS parameter, 0 when it is not set
+func parseQoSParameter(params map[string]string, key string) (int64, error) {
+	if params[key] == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseInt(params[key], 10, 64)
+	if err != nil || value < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "%s: %s must be a non-negative number", key, params[key])
+	}
+	return value, nil
+}
+
+// getScaledQoSLimit returns the limit for a volume of the given size, 0 meaning unlimited
+func getScaledQoSLimit(params map[string]string, limit scaledQoSLimit, sizeInKb int64) (int64, error) {
+	perGiB, err := parseQoSParameter(params, limit.perGiBKey)
+	if err != nil {
+		return 0, err
+	}
+	minLimit, err := parseQoSParameter(params, limit.minKey)
+	if err != nil {
+		return 0, err
+	}
+	maxLimit, err := parseQoSParameter(params, limit.maxKey)
+	if err != nil {
+		return 0, err
+	}
+
+	sizeInGiB := (sizeInKb + kiBytesInGiB - 1) / kiBytesInGiB
+	value := perGiB * sizeInGiB
+	if value < minLimit {
+		value = minLimit
+	}
+	if maxLimit > 0 && value > maxLimit {
+		value = maxLimit
+	}
+	if value == 0 {
+		return 0, nil
+	}
+
+	switch limit.absoluteKey {
+	case KeyIopsLimit:
+		if value < minIopsLimit {
+			value = minIopsLimit
+		}
+	case KeyBandwidthLimitInKbps:
+		value = (value + bandwidthLimitGranularity - 1) / bandwidthLimitGranularity * bandwidthLimitGranularity
+	}
+	return value, nil
+}
+
+// getEffectiveQoSContext returns the volume context with the per GiB QoS limits replaced by the
+// absolute limits for a volume of the given size. The volume context is returned as is when
+// no limit is given per GiB.
+func getEffectiveQoSContext(volumeContext map[string]string, sizeInKb int64) (map[string]string, error) {
+	if !hasScaledQoS(volumeContext) {
+		return volumeContext, nil
+	}
+	effective := make(map[string]string, len(volumeContext))
+	for key, value := range volumeContext {
+		effective[key] = value
+	}
+	for _, limit := range scaledQoSLimits {
+		if volumeContext[limit.perGiBKey] == "" {
+			continue
+		}
+		value, err := getScaledQoSLimit(volumeContext, limit, sizeInKb)
+		if err != nil {
+			return nil, err
+		}
+		effective[limit.absoluteKey] = strconv.FormatInt(value, 10)
+	}
+	return effective, nil
+}
+
+// setEffectiveQoSLimits records the absolute QoS limits of a new volume in its volume context, and
+// the name of its PersistentVolume for the QoS updates of ControllerExpandVolume
+func (s *service) setEffectiveQoSLimits(volume *csi.Volume, params map[string]string) error {
+	effective, err := getEffectiveQoSContext(volume.VolumeContext, volume.CapacityBytes/bytesInKiB)
+	if err != nil {
+		return err
+	}
+	volume.VolumeContext = effective
+	if pvName := params[CSIPersistentVolumeName]; pvName != "" {
+		s.pvNames.Store(volume.VolumeId, pvName)
+	}
+	return nil
+}
+
+// refreshScaledQoS sets the QoS limits of a resized volume on every host it is mapped to. The
+// mappings list both SDC and NVMe hosts, the limits of either are set by host ID.
+// The QoS parameters are read from the PersistentVolume as ControllerExpandVolume is not given
+// the volume context.
+func (s *service) refreshScaledQoS(ctx context.Context, systemID, csiVolID string, vol *siotypes.Volume, sizeInKb int64) error {
+	limited := false
+	for _, sdcInfo := range vol.MappedSdcInfo {
+		if sdcInfo.LimitIops > 0 || sdcInfo.LimitBwInMbps > 0 {
+			limited = true
+		}
+	}
+	if !limited {
+		return nil
+	}
+
+	attributes, err := s.getPVVolumeAttributes(ctx, csiVolID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not get QoS parameters of volume %s: %s", csiVolID, err.Error())
+	}
+	if !hasScaledQoS(attributes) {
+		return nil
+	}
+	effective, err := getEffectiveQoSContext(attributes, sizeInKb)
+	if err != nil {
+		return err
+	}
+
+	tgtVol := goscaleio.NewVolume(s.adminClients[systemID])
+	tgtVol.Volume = vol
+	for _, sdcInfo := range vol.MappedSdcInfo {
+		log.Infof("Setting QoS limits for volume %s of %d KiB mapped to host %s: IOPS %s, bandwidth %s KBps",
+			vol.Name, sizeInKb, sdcInfo.SdcID, effective[KeyIopsLimit], effective[KeyBandwidthLimitInKbps])
+		err := tgtVol.SetMappedSdcLimits(&siotypes.SetMappedSdcLimitsParam{
+			SdcID:                sdcInfo.SdcID,
+			BandwidthLimitInKbps: effective[KeyBandwidthLimitInKbps],
+			IopsLimit:            effective[KeyIopsLimit],
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "error setting QoS limits of volume %s on host %s: %s",
+				vol.Name, sdcInfo.SdcID, err.Error())
+		}
+	}
+	return nil
+}
+
+// getPVVolumeAttributes returns the volume attributes of the PersistentVolume of the CSI volume.
+// The PersistentVolume is read by name when it is known. The names of the PersistentVolumes
+// created before this controller started are learned from one list, served from the cache of
+// the API server.
+func (s *service) getPVVolumeAttributes(ctx context.Context, csiVolID string) (map[string]string, error) {
+	if K8sClientset == nil {
+		err := CreateKubeClientSet()
+		if err != nil {
+			return nil, status.Error(codes.Internal, GetMessage("init client failed with error: %v", err))
+		}
+		K8sClientset = k8sutils.Clientset
+	}
+	if pvName, ok := s.pvNames.Load(csiVolID); ok {
+		pv, err := K8sClientset.CoreV1().PersistentVolumes().Get(ctx, pvName.(string), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if err == nil && pv.Spec.CSI != nil && pv.Spec.CSI.VolumeHandle == csiVolID {
+			return pv.Spec.CSI.VolumeAttributes, nil
+		}
+		s.pvNames.Delete(csiVolID)
+	}
+
+	pvs, err := K8sClientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return nil, err
+	}
+	var attributes map[string]string
+	for _, pv := range pvs.Items {
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != Name {
+			continue
+		}
+		s.pvNames.Store(pv.Spec.CSI.VolumeHandle, pv.Name)
+		if pv.Spec.CSI.VolumeHandle == csiVolID {
+			attributes = pv.Spec.CSI.VolumeAttributes
+		}
+	}
+	if attributes == nil {
+		return nil, status.Errorf(codes.NotFound, "no PersistentVolume found for volume %s", csiVolID)
+	}
+	return attributes, nil
+}
@@ -0,0 +1,203 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateScaledQoSParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "no QoS",
+			params: map[string]string{},
+		},
+		{
+			name:   "per GiB limits with floor and ceiling",
+			params: map[string]string{KeyIopsLimitPerGiB: "50", KeyMinIopsLimit: "100", KeyMaxIopsLimit: "10000", KeyBandwidthLimitPerGiBInKbps: "1024"},
+		},
+		{
+			name:   "absolute IOPS with bandwidth per GiB",
+			params: map[string]string{KeyIopsLimit: "1000", KeyBandwidthLimitPerGiBInKbps: "1024"},
+		},
+		{
+			name:    "absolute and per GiB IOPS",
+			params:  map[string]string{KeyIopsLimit: "1000", KeyIopsLimitPerGiB: "50"},
+			wantErr: true,
+		},
+		{
+			name:    "not numeric",
+			params:  map[string]string{KeyBandwidthLimitPerGiBInKbps: "fast"},
+			wantErr: true,
+		},
+		{
+			name:    "negative",
+			params:  map[string]string{KeyIopsLimitPerGiB: "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "floor above ceiling",
+			params:  map[string]string{KeyIopsLimitPerGiB: "50", KeyMinIopsLimit: "1000", KeyMaxIopsLimit: "100"},
+			wantErr: true,
+		},
+		{
+			name:    "ceiling without per GiB limit",
+			params:  map[string]string{KeyMaxBandwidthLimitInKbps: "10240"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateScaledQoSParameters(tt.params)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetEffectiveQoSContext(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        map[string]string
+		sizeInKb      int64
+		wantIops      string
+		wantBandwidth string
+	}{
+		{
+			name:          "absolute limits are kept",
+			params:        map[string]string{KeyIopsLimit: "1000", KeyBandwidthLimitInKbps: "2048"},
+			sizeInKb:      8 * kiBytesInGiB,
+			wantIops:      "1000",
+			wantBandwidth: "2048",
+		},
+		{
+			name:          "scaled with size",
+			params:        map[string]string{KeyIopsLimitPerGiB: "50", KeyBandwidthLimitPerGiBInKbps: "1024"},
+			sizeInKb:      16 * kiBytesInGiB,
+			wantIops:      "800",
+			wantBandwidth: "16384",
+		},
+		{
+			name:     "partial GiB is rounded up",
+			params:   map[string]string{KeyIopsLimitPerGiB: "50"},
+			sizeInKb: 8*kiBytesInGiB + 1,
+			wantIops: "450",
+		},
+		{
+			name:          "floor",
+			params:        map[string]string{KeyIopsLimitPerGiB: "10", KeyMinIopsLimit: "500", KeyBandwidthLimitPerGiBInKbps: "100", KeyMinBandwidthLimitInKbps: "4096"},
+			sizeInKb:      8 * kiBytesInGiB,
+			wantIops:      "500",
+			wantBandwidth: "4096",
+		},
+		{
+			name:          "ceiling",
+			params:        map[string]string{KeyIopsLimitPerGiB: "50", KeyMaxIopsLimit: "2000", KeyBandwidthLimitPerGiBInKbps: "1024", KeyMaxBandwidthLimitInKbps: "10240"},
+			sizeInKb:      1024 * kiBytesInGiB,
+			wantIops:      "2000",
+			wantBandwidth: "10240",
+		},
+		{
+			name:          "array granularity",
+			params:        map[string]string{KeyIopsLimitPerGiB: "1", KeyBandwidthLimitPerGiBInKbps: "100"},
+			sizeInKb:      8 * kiBytesInGiB,
+			wantIops:      "11",
+			wantBandwidth: "1024",
+		},
+		{
+			name:          "unlimited",
+			params:        map[string]string{KeyIopsLimitPerGiB: "0", KeyBandwidthLimitPerGiBInKbps: "0"},
+			sizeInKb:      8 * kiBytesInGiB,
+			wantIops:      "0",
+			wantBandwidth: "0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			effective, err := getEffectiveQoSContext(tt.params, tt.sizeInKb)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIops, effective[KeyIopsLimit])
+			assert.Equal(t, tt.wantBandwidth, effective[KeyBandwidthLimitInKbps])
+		})
+	}
+}
+
+func TestSetEffectiveQoSLimits(t *testing.T) {
+	volume := &csi.Volume{
+		VolumeId:      "sys1-vol1",
+		CapacityBytes: 8 * bytesInGiB,
+		VolumeContext: map[string]string{KeyIopsLimitPerGiB: "100"},
+	}
+	s := &service{}
+	assert.NoError(t, s.setEffectiveQoSLimits(volume, map[string]string{CSIPersistentVolumeName: "pv1"}))
+	assert.Equal(t, "800", volume.VolumeContext[KeyIopsLimit])
+	assert.Equal(t, "100", volume.VolumeContext[KeyIopsLimitPerGiB])
+	pvName, ok := s.pvNames.Load("sys1-vol1")
+	assert.True(t, ok)
+	assert.Equal(t, "pv1", pvName)
+}
+
+func TestGetPVVolumeAttributes(t *testing.T) {
+	defer func() { K8sClientset = nil }()
+	K8sClientset = fake.NewSimpleClientset(&corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv1"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           Name,
+					VolumeHandle:     "sys1-vol1",
+					VolumeAttributes: map[string]string{KeyIopsLimitPerGiB: "100"},
+				},
+			},
+		},
+	})
+
+	s := &service{}
+	attributes, err := s.getPVVolumeAttributes(context.Background(), "sys1-vol1")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", attributes[KeyIopsLimitPerGiB])
+	pvName, ok := s.pvNames.Load("sys1-vol1")
+	assert.True(t, ok)
+	assert.Equal(t, "pv1", pvName)
+
+	// a known PersistentVolume is read by name
+	K8sClientset.(*fake.Clientset).ClearActions()
+	attributes, err = s.getPVVolumeAttributes(context.Background(), "sys1-vol1")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", attributes[KeyIopsLimitPerGiB])
+	actions := K8sClientset.(*fake.Clientset).Actions()
+	assert.Len(t, actions, 1)
+	assert.Equal(t, "get", actions[0].GetVerb())
+
+	// a stale name is forgotten
+	s.pvNames.Store("sys1-vol2", "pv2")
+	_, err = s.getPVVolumeAttributes(context.Background(), "sys1-vol2")
+	assert.Error(t, err)
+	_, ok = s.pvNames.Load("sys1-vol2")
+	assert.False(t, ok)
+}
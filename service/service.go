@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -150,6 +151,12 @@ type ArrayConnectionData struct {
 	OidcClientSecret          string            `json:"oidcClientSecret,omitempty"`
 	Issuer                    string            `json:"issuer,omitempty"`
 	Scopes                    string            `json:"scopes,omitempty"`
+	// DH-HMAC-CHAP secret the node authenticates its NVMe/TCP sessions with
+	NVMeHostKey string `json:"nvmeHostKey,omitempty"`
+	// DH-HMAC-CHAP secret the array authenticates with, for bidirectional authentication
+	NVMeControllerKey string `json:"nvmeControllerKey,omitempty"`
+	// TLS PSK, in the interchange format, to encrypt the NVMe/TCP sessions with
+	NVMeTLSKey string `json:"nvmeTLSKey,omitempty"`
 }
 
 // Definitions to make AvailabilityZone decomposition easier to read.
@@ -234,6 +241,25 @@ type Opts struct {
 	PodmonPort                 string // to indicates the port to be used for exposing podmon API health
 	PodmonPollingFreq          string // indicates the polling frequency to check array connectivity
 	AuthType                   string // indicate what auth type to use
+	DataMoverNodeID            string // node ID of the node the controller copies volumes between systems on
+	DataMoverStateDir          string // directory keeping the progress of copies between systems
+
+	// how often lost NVMe/TCP paths are looked for, 0 disables the check
+	NVMePathCheckInterval time.Duration
+	// port serving the NVMe/TCP path counts and the trims of the node volumes
+	NodeMetricsPort string
+	// repair inconsistent NVMe staging states instead of failing the stage request
+	NVMeStageRepair bool
+	// how often orphaned private and staging mounts are looked for, the reconciliation is disabled unless set
+	MountReconcileInterval time.Duration
+	// only log the orphaned mounts, unless set to false
+	MountReconcileDryRun bool
+	// lazily unmount busy volumes held only by mounts of other mount namespaces
+	LazyUnmountOnBusy bool
+	// how often the filesystem volumes created with periodicTrim are trimmed, 0 disables the trims
+	FstrimInterval time.Duration
+	// rename the NVMe hosts of nodes also using SDC after their SDC GUID, changing their node ID
+	RenameNVMeHosts bool
 }
 
 type PlatformInfo struct {
@@ -243,6 +269,9 @@ type PlatformInfo struct {
 }
 
 type service struct {
+	csi.UnimplementedIdentityServer
+	csi.UnimplementedControllerServer
+	csi.UnimplementedNodeServer
 	opts                Opts
 	adminClients        map[string]*sio.Client
 	systems             map[string]*sio.System
@@ -263,15 +292,31 @@ type service struct {
 	nvmeTargetNqn           map[string]string
 	nvmeConnector           NVMEConnector
 	nvmeLib                 gonvme.NVMEinterface
-	useNVME                 bool
-	useSDC                  bool
+	useNVME                 bool              // NVMe/TCP is used for at least one array
+	useSDC                  bool              // SDC is used for at least one array
+	blockProtocols          map[string]string // block protocol used for each array, see blockProtocol
+	keepNVMeNodeID          bool              // node ID is the name of an NVMe host created before the node used SDC, see getNodeID
 	nodeID                  string
 	probeStatus             *sync.Map
 	probeLocks              sync.Map // map[string]*sync.Mutex
+	nasSelectionCounters    sync.Map // map[string]*atomic.Uint64, round robin position per NAS server list
+	arrayHTTPClients        sync.Map // map[string]*http.Client, REST client of each array, see arrayHTTPClient
+	dataMoverJobs           sync.Map // map[string]*dataMoverJob, copies between systems running in this controller
+	migratingVolumes        sync.Map // map[string]bool, block volumes migrating between storage pools, see getMigrationCondition
+	pvNames                 sync.Map // map[string]string, PersistentVolume names of the CSI volumes, see getPVVolumeAttributes
+	nvmeExpectedPaths       sync.Map // map[string]int, NVMe/TCP portals of each array
+	nvmeVolumePaths         sync.Map // map[string]nvmeVolumePaths, NVMe/TCP paths of the volumes connected to this node
+	nvmeAuth                nvmeAuthConfig
+	nvmePortalFilters       nvmePortalFilters
+	nvmeStageRepairs        sync.Map // map[StageStatus]*atomic.Int64, NVMe staging states repaired
+	fstrimResults           sync.Map // map[string]fstrimResult, last trim of the volumes mounted on this node
+	fstrimPVs               sync.Map // map[string]*fstrimVolume, PersistentVolumes mounted on this node, see getFstrimPV
+	nodeEvents              nodeEventRecorder
 }
 
 type Config struct {
-	InterfaceNames map[string]string `yaml:"interfaceNames"`
+	InterfaceNames    map[string]string  `yaml:"interfaceNames"`
+	NVMePortalFilters []NVMePortalFilter `yaml:"nvmePortalFilters"`
 }
 
 type GetIPAddressByInterfacefunc func(string, NetworkInterface) (string, error)
@@ -334,6 +379,9 @@ func (s *service) ProcessMapSecretChange() error {
 		if err = s.logCsiNodeTopologyKeys(); err != nil {
 			log.Errorf("unable to log csiNode topology keys: %v", err)
 		}
+		if s.isNodeMode() {
+			s.rotateNVMeKeys()
+		}
 	})
 	return nil
 }
@@ -439,6 +487,8 @@ func (s *service) updateDriverConfigParams(v *viper.Viper) error {
 	csmlog.SetLevel(level)
 	// set X_CSI_LOG_LEVEL so that gocsi doesn't overwrite the loglevel set by us
 	_ = os.Setenv(gocsi.EnvVarLogLevel, level.String())
+
+	s.loadNVMePortalFilters(v.ConfigFileUsed())
 	return nil
 }
 
@@ -586,6 +636,60 @@ func (s *service) BeforeServe(
 		opts.KubeNodeName = kubeNodeName
 	}
 
+	if dataMoverNodeID, ok := csictx.LookupEnv(ctx, EnvDataMoverNodeID); ok {
+		opts.DataMoverNodeID = strings.TrimSpace(dataMoverNodeID)
+	}
+	if dataMoverStateDir, ok := csictx.LookupEnv(ctx, EnvDataMoverStateDir); ok {
+		opts.DataMoverStateDir = dataMoverStateDir
+	}
+	if opts.DataMoverStateDir == "" {
+		opts.DataMoverStateDir = defaultDataMoverStateDir
+	}
+
+	opts.NVMePathCheckInterval = defaultNVMePathCheckInterval
+	if interval, ok := csictx.LookupEnv(ctx, EnvNVMePathCheckInterval); ok {
+		duration, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Warnf("error while parsing env variable '%s', %s, defaulting to %s", EnvNVMePathCheckInterval, err, defaultNVMePathCheckInterval)
+		} else {
+			opts.NVMePathCheckInterval = duration
+		}
+	}
+	if metricsPort, ok := csictx.LookupEnv(ctx, EnvNodeMetricsPort); ok && metricsPort != "" {
+		opts.NodeMetricsPort = fmt.Sprintf(":%s", metricsPort)
+	}
+	opts.NVMeStageRepair = true
+	if stageRepair, ok := csictx.LookupEnv(ctx, EnvNVMeStageRepair); ok {
+		opts.NVMeStageRepair = !strings.EqualFold(stageRepair, "false")
+	}
+	if interval, ok := csictx.LookupEnv(ctx, EnvMountReconcileInterval); ok && interval != "" {
+		duration, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Warnf("error while parsing env variable '%s', %s, the reconciliation of orphaned mounts is disabled", EnvMountReconcileInterval, err)
+		} else {
+			opts.MountReconcileInterval = duration
+		}
+	}
+	opts.MountReconcileDryRun = true
+	if dryRun, ok := csictx.LookupEnv(ctx, EnvMountReconcileDryRun); ok {
+		opts.MountReconcileDryRun = !strings.EqualFold(dryRun, "false")
+	}
+	if lazyUnmount, ok := csictx.LookupEnv(ctx, EnvLazyUnmountOnBusy); ok {
+		opts.LazyUnmountOnBusy = strings.EqualFold(lazyUnmount, "true")
+	}
+	opts.FstrimInterval = defaultFstrimInterval
+	if interval, ok := csictx.LookupEnv(ctx, EnvFstrimInterval); ok {
+		duration, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Warnf("error while parsing env variable '%s', %s, defaulting to %s", EnvFstrimInterval, err, defaultFstrimInterval)
+		} else {
+			opts.FstrimInterval = duration
+		}
+	}
+	if renameNVMeHosts, ok := csictx.LookupEnv(ctx, EnvRenameNVMeHosts); ok {
+		opts.RenameNVMeHosts = strings.EqualFold(renameNVMeHosts, "true")
+	}
+
 	if isPodmonEnabled, ok := csictx.LookupEnv(ctx, EnvPodmonEnabled); ok {
 		opts.IsPodmonEnabled = strings.EqualFold(isPodmonEnabled, "true")
 	}
@@ -654,6 +758,7 @@ func (s *service) BeforeServe(
 		log.Errorf("can not get initiators of the node: %s", err.Error())
 	}
 
+	s.blockProtocols = make(map[string]string)
 	for _, arr := range s.opts.arrays {
 		log.Infof("checking array version for array: %s", arr.SystemID)
 		version, err := s.getArrayVersion(ctx, arr.SystemID)
@@ -663,27 +768,37 @@ func (s *service) BeforeServe(
 			log.Infof("array version: %f", version)
 		}
 
+		protocol := ""
 		switch arr.BlockProtocol {
 		case NVMeTCP:
-			log.Infof("block protocol is set to NVMeTCP")
+			log.Infof("block protocol is set to NVMeTCP for array %s", arr.SystemID)
 			if version < 4.0 {
 				log.Warnf("NVMeTCP transport is not supported for array version %f", version)
 			}
 			if len(nvmeInitiators) == 0 {
 				log.Errorf("NVMeTCP transport was requested but NVMe initiator is not available")
 			}
-			s.useNVME = true
+			protocol = NVMeTCP
 		case SDC:
-			log.Infof("block protocol is set to SDC")
-			s.useSDC = true
+			log.Infof("block protocol is set to SDC for array %s", arr.SystemID)
+			protocol = SDC
 		case "auto":
-			log.Infof("block protocol is set to auto — node will be either SDC or NVMe, or SDC by default")
-			s.configureAutoBlockProtocol(ctx, version, len(nvmeInitiators))
+			log.Infof("block protocol is set to auto for array %s — SDC or NVMe, or SDC by default", arr.SystemID)
+			protocol = s.configureAutoBlockProtocol(ctx, version, len(nvmeInitiators))
 		default:
-			log.Infof("block protocol is not set, defaulting to NFS")
+			log.Infof("block protocol is not set for array %s, defaulting to NFS", arr.SystemID)
 		}
+		s.setBlockProtocol(arr.SystemID, protocol)
+	}
 
-		if s.useNVME {
+	// NVMe hosts are named after the node ID, which depends on the protocols of all the arrays
+	if s.useNVME && s.useSDC && s.opts.SdcGUID == "" {
+		if err := s.nodeProbe(ctx); err != nil {
+			log.Errorf("can not get SDC GUID of the node: %s", err.Error())
+		}
+	}
+	for _, arr := range s.opts.arrays {
+		if s.blockProtocol(arr.SystemID) == NVMeTCP {
 			if err := s.setupNVMeHost(nvmeInitiators, arr.SystemID); err != nil {
 				log.Errorf("can not setup NVMe host for array: %s", err.Error())
 			}
@@ -696,6 +811,17 @@ func (s *service) BeforeServe(
 
 		// Start the podmon API service
 		go s.startAPIService(ctx)
+
+		if s.useNVME {
+			go s.startNVMePathWatchdog(ctx)
+		}
+
+		go s.startMountReconciler(ctx)
+		go s.startFstrimScheduler(ctx)
+
+		if s.opts.NodeMetricsPort != "" {
+			go s.nodeMetricsRouter()
+		}
 	}
 
 	if _, ok := csictx.LookupEnv(ctx, "X_CSI_VXFLEXOS_NO_PROBE_ON_START"); !ok {
@@ -712,7 +838,9 @@ func (s *service) BeforeServe(
 	return nil
 }
 
-func (s *service) configureAutoBlockProtocol(ctx context.Context, version float64, nvmeInitiators int) {
+// configureAutoBlockProtocol returns the block protocol of an array set to auto: SDC when the
+// SDC is installed, else NVMeTCP when supported by the node and the array, else empty for NFS only
+func (s *service) configureAutoBlockProtocol(ctx context.Context, version float64, nvmeInitiators int) string {
 	log := log.WithContext(ctx)
 	// do nodeProbe to detect SDC
 	if err := s.nodeProbe(ctx); err != nil {
@@ -725,13 +853,86 @@ func (s *service) configureAutoBlockProtocol(ctx context.Context, version float6
 	switch {
 	case isSDC:
 		log.Infof("SDC available, using SDC")
-		s.useSDC = true
+		return SDC
 	case isNVMe:
 		log.Infof("NVMeTCP available, using NVMeTCP")
-		s.useNVME = true
+		return NVMeTCP
 	default:
 		log.Info("neither SDC nor NVMeTCP detected, using NFS")
+		return ""
+	}
+}
+
+// setBlockProtocol records the block protocol the node uses for the array
+func (s *service) setBlockProtocol(systemID, protocol string) {
+	if s.blockProtocols == nil {
+		s.blockProtocols = make(map[string]string)
+	}
+	s.blockProtocols[systemID] = protocol
+	switch protocol {
+	case NVMeTCP:
+		s.useNVME = true
+	case SDC:
+		s.useSDC = true
+	}
+}
+
+// blockProtocol returns the block protocol the node uses for the array: SDC, NVMeTCP, or empty
+// when only NFS is used
+func (s *service) blockProtocol(systemID string) string {
+	if systemID == "" {
+		systemID = s.opts.defaultSystemID
+	}
+	if protocol, ok := s.blockProtocols[systemID]; ok {
+		return protocol
+	}
+	// arrays not resolved in BeforeServe follow the protocol of the node
+	switch {
+	case s.useNVME:
+		return NVMeTCP
+	case s.useSDC:
+		return SDC
+	}
+	return ""
+}
+
+// isNVMeSystem returns true when the node connects the volumes of the array through NVMe/TCP
+func (s *service) isNVMeSystem(systemID string) bool {
+	return s.blockProtocol(systemID) == NVMeTCP
+}
+
+// hasAutoBlockProtocol returns true when an array picks its block protocol from the SDC probe
+func (s *service) hasAutoBlockProtocol() bool {
+	for _, arr := range s.opts.arrays {
+		if arr.BlockProtocol == "auto" {
+			return true
+		}
+	}
+	return false
+}
+
+// getNodeID returns the node ID reported by NodeGetInfo. Nodes using SDC keep the SDC GUID even
+// when some arrays use NVMe/TCP, their NVMe hosts are then named after it, see nvmeHostName.
+// Nodes whose NVMe host was created under another name keep that name as node ID, as they
+// reported it before they used SDC for other arrays, until the host is renamed.
+func (s *service) getNodeID() string {
+	if s.useNVME && (!s.useSDC || s.opts.SdcGUID == "" || s.keepNVMeNodeID) {
+		return s.nodeID
+	}
+	return s.opts.SdcGUID
+}
+
+// maxHostNameLen is the longest name of a PowerFlex host
+const maxHostNameLen = 31
+
+// nvmeHostName returns the name of the NVMe host of a node identified by its SDC GUID. The GUID
+// is longer than the 31 characters allowed for a host name, so its hex digits are used instead.
+func nvmeHostName(sdcGUID string) string {
+	name := "nvme-" + strings.ToLower(strings.ReplaceAll(sdcGUID, "-", ""))
+	if len(name) > maxHostNameLen {
+		name = name[:maxHostNameLen]
 	}
+	return name
 }
 
 func (s *service) setupNVMeHost(nvmeInitiators []string, systemID string) error {
@@ -758,21 +959,43 @@ func (s *service) setupNVMeHost(nvmeInitiators []string, systemID string) error
 	}
 
 	// Get node ID
-	s.nodeID, err = s.generateNodeID()
-	if err != nil {
-		log.Errorf("failed to generate node ID: %s", err.Error())
-		return err
+	mixed := s.useSDC && s.opts.SdcGUID != "" && !s.keepNVMeNodeID
+	if mixed {
+		s.nodeID = nvmeHostName(s.opts.SdcGUID)
+	} else if !s.keepNVMeNodeID {
+		s.nodeID, err = s.generateNodeID()
+		if err != nil {
+			log.Errorf("failed to generate node ID: %s", err.Error())
+			return err
+		}
 	}
 
+	defer func() { s.warnNVMeHostAuth(systemID, s.nodeID) }()
+
 	for _, host := range hosts {
 		if host.Name == s.nodeID {
 			log.Infof("host with same name already exists: %s", host.Name)
 			return nil
 		}
 		if host.Nqn != "" && slices.Contains(nvmeInitiators, host.Nqn) {
-			s.nodeID = host.Name
-			log.Infof("Found existing host with matching NQN: %s", host.Name)
-			return nil
+			if !mixed {
+				s.nodeID = host.Name
+				log.Infof("Found existing host with matching NQN: %s", host.Name)
+				return nil
+			}
+			// the node reported this host name as node ID before it used SDC for other arrays,
+			// renaming the host changes the node ID to the SDC GUID
+			if !s.opts.RenameNVMeHosts {
+				log.Warnf("Keeping NVMe host %s with matching NQN and node ID %s, the volumes of the SDC arrays can't be published to this node: "+
+					"drain the node and set %s to rename the host to %s and use SDC GUID %s as node ID",
+					host.Name, host.Name, EnvRenameNVMeHosts, s.nodeID, s.opts.SdcGUID)
+				s.nodeID = host.Name
+				s.keepNVMeNodeID = true
+				return nil
+			}
+			log.Warnf("Renaming NVMe host %s with matching NQN to %s, node ID changes from %s to SDC GUID %s",
+				host.Name, s.nodeID, host.Name, s.opts.SdcGUID)
+			return s.adminClients[systemID].RenameSdc(host.ID, s.nodeID)
 		}
 	}
 
@@ -804,7 +1027,7 @@ func (s *service) generateNodeID() (string, error) {
 
 	hashedNodeID := hashNodeID(nodeUID)
 	nodeID := fmt.Sprintf("%s-%s", nodeIP, hashedNodeID)
-	return nodeID[:31], nil
+	return nodeID[:maxHostNameLen], nil
 }
 
 func (s *service) updateConfigMap(getIPAddressByInterfacefunc GetIPAddressByInterfacefunc, configFilePath string) {
@@ -1014,6 +1237,7 @@ func (s *service) RegisterAdditionalServers(server *grpc.Server) {
 	podmon.RegisterPodmonServer(server, s)
 	volumeGroupSnapshot.RegisterVolumeGroupSnapshotServer(server, s)
 	replication.RegisterReplicationServer(server, s)
+	csi.RegisterGroupControllerServer(server, &groupControllerService{s: s})
 }
 
 // getVolProvisionType returns a string indicating thin or thick provisioning
@@ -1304,6 +1528,9 @@ func getArrayConfig(_ context.Context) (map[string]*ArrayConnectionData, error)
 				log.Infof("BlockProtocol is not set, defaulting to auto")
 				c.BlockProtocol = "auto"
 			}
+			if err := c.validateNVMeAuth(); err != nil {
+				return nil, fmt.Errorf("invalid NVMe authentication at index %d: %v", i, err)
+			}
 			// ArrayConnectionData
 			if c.AllSystemNames != "" {
 				names := strings.Split(c.AllSystemNames, ",")
@@ -1327,6 +1554,7 @@ func getArrayConfig(_ context.Context) (map[string]*ArrayConnectionData, error)
 				"allSystemNames":            c.AllSystemNames,
 				"nasName":                   c.NasName,
 				"blockProtocol":             c.BlockProtocol,
+				"nvmeAuthentication":        c.hasNVMeAuth(),
 			}
 
 			log.WithFields(fields).Infof("configured %s", c.SystemID)
@@ -1563,6 +1791,19 @@ func externalAccessAlreadyAdded(export *siotypes.NFSExport, externalAccess strin
 	return false
 }
 
+// nfsExportHasHost returns true if any of the given IPs has access to the export in any access mode.
+// Hosts are stored on the array with a netmask, so both forms are checked.
+func nfsExportHasHost(export *siotypes.NFSExport, ips []string) bool {
+	for _, ip := range ips {
+		for _, host := range []string{ip, ip + "/255.255.255.255"} {
+			if Contains(export.ReadWriteRootHosts, host) || Contains(export.ReadWriteHosts, host) || Contains(export.ReadOnlyRootHosts, host) || Contains(export.ReadOnlyHosts, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *service) unexportFilesystem(_ context.Context, _ *csi.ControllerUnpublishVolumeRequest, client *goscaleio.Client, fs *siotypes.FileSystem, volumeContextID string, nodeIPs []string, nodeID string) error {
 	nfsExportName := NFSExportNamePrefix + fs.Name
 	nfsExportExists := false
@@ -2061,22 +2302,6 @@ func (s *service) expandReplicationPair(ctx context.Context, req *csi.Controller
 	return nil
 }
 
-func (s *service) getNASServerIDFromName(systemID, nasName string) (string, error) {
-	if nasName == "" {
-		log.Infof("NAS server not provided.")
-		return "", errors.New("NAS server not provided")
-	}
-	system, err := s.adminClients[systemID].FindSystem(systemID, "", "")
-	if err != nil {
-		return "", err
-	}
-	nas, err := system.GetNASByIDName("", nasName)
-	if err != nil {
-		return "", err
-	}
-	return nas.ID, nil
-}
-
 func (s *service) GetNfsTopology(systemID string) []*csi.Topology {
 	nfsTopology := new(csi.Topology)
 	nfsTopology.Segments = map[string]string{Name + "/" + systemID + "-nfs": "true"}
@@ -2151,6 +2376,74 @@ func (s *service) GetNodeIPByCSINodeID(nodeID string) string {
 	return ""
 }
 
+// arrayRESTRequest sends a request to a REST endpoint of the array that goscaleio does not wrap,
+// decoding the JSON response into out. The session token of the admin client is reused.
+func (s *service) arrayRESTRequest(ctx context.Context, systemID, method, path string, body, out interface{}) error {
+	array, ok := s.opts.arrays[systemID]
+	if !ok || array.Endpoint == "" {
+		return fmt.Errorf("array configuration not found for system: %s", systemID)
+	}
+	client := s.adminClients[systemID]
+	if client == nil {
+		return fmt.Errorf("no client found for system: %s", systemID)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(array.Endpoint, "/")+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+client.GetToken())
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.arrayHTTPClient(systemID, array.SkipCertificateValidation || array.Insecure).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // #nosec G307
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, resp.StatusCode, string(respBody))
+	}
+	if out != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+// arrayHTTPClient returns the HTTP client used for the REST requests to an array, shared by the
+// requests so their connections are reused. A change of the certificate validation setting of
+// the array secret gets a new client.
+func (s *service) arrayHTTPClient(systemID string, insecure bool) *http.Client {
+	key := fmt.Sprintf("%s:%t", systemID, insecure)
+	if client, ok := s.arrayHTTPClients.Load(key); ok {
+		return client.(*http.Client)
+	}
+	client, _ := s.arrayHTTPClients.LoadOrStore(key, &http.Client{
+		Timeout: Timeout,
+		Transport: &http.Transport{
+			// #nosec G402 -- mirrors the skipCertificateValidation setting of the array secret
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		},
+	})
+	return client.(*http.Client)
+}
+
 // QueryArrayStatus make API call to the specified url to retrieve connection status
 func (s *service) QueryArrayStatus(ctx context.Context, url string) (bool, error) {
 	defer func() {
@@ -2487,6 +2780,11 @@ func (s *service) getHostIDAndType(systemID, nodeID string) (string, string, err
 		nvmeHost, err := s.systems[systemID].FindSdc("Name", nodeID)
 		if err != nil {
 			log.Infof("No NVME host found for nodeID %s: %v", nodeID, err)
+			// nodes using SDC for other arrays name their NVMe hosts after the SDC GUID
+			nvmeHost, err = s.systems[systemID].FindSdc("Name", nvmeHostName(nodeID))
+			if err != nil {
+				log.Infof("No NVME host found for nodeID %s: %v", nvmeHostName(nodeID), err)
+			}
 		}
 		if nvmeHost != nil {
 			log.Infof("NVME Host with ID %s found for nodeID %s", nvmeHost.Sdc.ID, nodeID)
@@ -731,34 +731,33 @@ func TestFindNetworkInterfaceIPs(t *testing.T) {
 
 func TestConfigureAutoBlockProtocol(t *testing.T) {
 	tests := []struct {
-		name            string
-		version         float64
-		nvmeInitiators  int
-		sdcGUID         string
-		nodeProbeErr    error
-		expectedUseSDC  bool
-		expectedUseNVME bool
+		name           string
+		version        float64
+		nvmeInitiators int
+		sdcGUID        string
+		nodeProbeErr   error
+		expected       string
 	}{
 		{
 			name:           "Both SDC and NVMeTCP available",
 			version:        4.5,
 			nvmeInitiators: 1,
 			sdcGUID:        "some-guid",
-			expectedUseSDC: true,
+			expected:       SDC,
 		},
 		{
-			name:            "Only NVMeTCP available",
-			version:         4.5,
-			nvmeInitiators:  1,
-			sdcGUID:         "",
-			expectedUseNVME: true,
+			name:           "Only NVMeTCP available",
+			version:        4.5,
+			nvmeInitiators: 1,
+			sdcGUID:        "",
+			expected:       NVMeTCP,
 		},
 		{
 			name:           "Only SDC available",
 			version:        3.9,
 			nvmeInitiators: 0,
 			sdcGUID:        "some-guid",
-			expectedUseSDC: true,
+			expected:       SDC,
 		},
 		{
 			name:           "Neither SDC nor NVMeTCP available",
@@ -778,18 +777,47 @@ func TestConfigureAutoBlockProtocol(t *testing.T) {
 				},
 			}
 
-			svc.configureAutoBlockProtocol(context.Background(), tt.version, tt.nvmeInitiators)
+			protocol := svc.configureAutoBlockProtocol(context.Background(), tt.version, tt.nvmeInitiators)
 
-			if svc.useSDC != tt.expectedUseSDC {
-				t.Errorf("expected useSDC=%v, got %v", tt.expectedUseSDC, svc.useSDC)
-			}
-			if svc.useNVME != tt.expectedUseNVME {
-				t.Errorf("expected useNVME=%v, got %v", tt.expectedUseNVME, svc.useNVME)
+			if protocol != tt.expected {
+				t.Errorf("expected protocol=%q, got %q", tt.expected, protocol)
 			}
 		})
 	}
 }
 
+func TestPerArrayBlockProtocol(t *testing.T) {
+	guid := "6A2D8D8B-2AE0-4A19-B1A7-6A1A6B0F2E10"
+	svc := &service{
+		nodeID: "10.0.0.1-0123456789abcdef0123456",
+		opts:   Opts{SdcGUID: guid, defaultSystemID: "sdcSystem"},
+	}
+	svc.setBlockProtocol("sdcSystem", SDC)
+	svc.setBlockProtocol("nvmeSystem", NVMeTCP)
+	svc.setBlockProtocol("nfsSystem", "")
+
+	assert.True(t, svc.useSDC)
+	assert.True(t, svc.useNVME)
+	assert.Equal(t, SDC, svc.blockProtocol("sdcSystem"))
+	assert.Equal(t, SDC, svc.blockProtocol(""))
+	assert.True(t, svc.isNVMeSystem("nvmeSystem"))
+	assert.False(t, svc.isNVMeSystem("sdcSystem"))
+	assert.Equal(t, "", svc.blockProtocol("nfsSystem"))
+
+	// a node mixing both protocols keeps the SDC GUID as node ID
+	assert.Equal(t, guid, svc.getNodeID())
+	assert.Equal(t, "nvme-6a2d8d8b2ae04a19b1a76a1a6b", nvmeHostName(guid))
+	assert.Len(t, nvmeHostName(guid), maxHostNameLen)
+
+	// unless its NVMe host was created before it used SDC and is not renamed
+	svc.keepNVMeNodeID = true
+	assert.Equal(t, svc.nodeID, svc.getNodeID())
+
+	pureNVMe := &service{useNVME: true, nodeID: "10.0.0.1-0123456789abcdef0123456"}
+	assert.Equal(t, pureNVMe.nodeID, pureNVMe.getNodeID())
+	assert.True(t, pureNVMe.isNVMeSystem("anySystem"))
+}
+
 // helper to build a server returning the given status and code
 func newStatusServer(status ArrayConnectivityStatus, httpCode int) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -936,3 +964,33 @@ func TestQueryArrayStatus_AllScenarios(t *testing.T) {
 		})
 	}
 }
+
+func TestNfsExportHasHost(t *testing.T) {
+	export := &siotypes.NFSExport{
+		ReadWriteRootHosts: []string{"10.0.0.1/255.255.255.255"},
+		ReadOnlyRootHosts:  []string{"10.0.0.2"},
+	}
+
+	tests := []struct {
+		name string
+		ips  []string
+		want bool
+	}{
+		{name: "host with netmask", ips: []string{"10.0.0.1"}, want: true},
+		{name: "host without netmask", ips: []string{"10.0.0.2"}, want: true},
+		{name: "one of several node IPs", ips: []string{"192.168.1.1", "10.0.0.1"}, want: true},
+		{name: "host not in export", ips: []string{"10.0.0.3"}, want: false},
+		{name: "no node IPs", ips: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nfsExportHasHost(export, tt.ips))
+		})
+	}
+}
+
+func TestStatWithTimeout(t *testing.T) {
+	assert.NoError(t, statWithTimeout(os.TempDir(), time.Second))
+	assert.Error(t, statWithTimeout("/there/is/nothing/here", time.Second))
+}
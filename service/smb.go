@@ -0,0 +1,350 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/dell/gofsutil"
+	"github.com/dell/goscaleio"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SMBFsType is the fsType used to request SMB/CIFS filesystem volumes
+	SMBFsType = "cifs"
+
+	// SMBShareNamePrefix is the prefix used for SMB shares created using csi-powerflex driver
+	SMBShareNamePrefix = "csishare-"
+
+	// KeySMBShare is the publish context key holding the UNC path of the SMB share
+	KeySMBShare = "smbShare"
+
+	// SMB credential keys expected in the node publish secret
+	smbSecretUsername = "username"
+	smbSecretPassword = "password"
+	smbSecretDomain   = "domain"
+
+	smbSharesURI = "/rest/v1/smb-shares"
+)
+
+// smbShare is the SMB share object returned by the PowerFlex file REST API
+type smbShare struct {
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name"`
+	FileSystemID string `json:"file_system_id"`
+	Path         string `json:"path"`
+	Description  string `json:"description,omitempty"`
+}
+
+// isFileFsType returns true when fsType is served from a PowerFlex filesystem instead of a block volume
+func isFileFsType(fsType string) bool {
+	return fsType == "nfs" || fsType == SMBFsType
+}
+
+// GetSmbTopology returns the topology segment published by nodes able to mount SMB volumes of the system
+func (s *service) GetSmbTopology(systemID string) []*csi.Topology {
+	smbTopology := new(csi.Topology)
+	smbTopology.Segments = map[string]string{Name + "/" + systemID + "-smb": "true"}
+	return []*csi.Topology{smbTopology}
+}
+
+// smbMountSupported returns true when the node has the cifs mount helper installed
+func smbMountSupported() bool {
+	_, err := exec.LookPath("mount.cifs")
+	return err == nil
+}
+
+// getFilesystemTopology returns the NFS or SMB topology depending on fsType
+func (s *service) getFilesystemTopology(systemID string, fsType string) []*csi.Topology {
+	if fsType == SMBFsType {
+		return s.GetSmbTopology(systemID)
+	}
+	return s.GetNfsTopology(systemID)
+}
+
+// smbShareName returns the name of the SMB share giving a node access to the filesystem. Every
+// node gets its own share, so unpublishing the volume from a node revokes the access of that node
+// only.
+func smbShareName(fsName, nodeID string) string {
+	sum := sha256.Sum256([]byte(nodeID))
+	return SMBShareNamePrefix + fsName + "-" + hex.EncodeToString(sum[:4])
+}
+
+// getSMBShares returns the SMB shares of the filesystem
+func (s *service) getSMBShares(ctx context.Context, systemID string, fs *siotypes.FileSystem) ([]smbShare, error) {
+	// refresh the session token before talking to the REST endpoint directly
+	if _, err := s.adminClients[systemID].FindSystem(systemID, "", ""); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("select", "*")
+	query.Set("file_system_id", "eq."+fs.ID)
+	var shares []smbShare
+	if err := s.arrayRESTRequest(ctx, systemID, http.MethodGet, smbSharesURI+"?"+query.Encode(), nil, &shares); err != nil {
+		return nil, err
+	}
+	fsShares := make([]smbShare, 0, len(shares))
+	for _, share := range shares {
+		if share.FileSystemID == fs.ID {
+			fsShares = append(fsShares, share)
+		}
+	}
+	return fsShares, nil
+}
+
+// getSMBShare returns the SMB share of the filesystem for the node, or nil if it has none
+func (s *service) getSMBShare(ctx context.Context, systemID string, fs *siotypes.FileSystem, nodeID string) (*smbShare, error) {
+	shares, err := s.getSMBShares(ctx, systemID, fs)
+	if err != nil {
+		return nil, err
+	}
+	name := smbShareName(fs.Name, nodeID)
+	for i := range shares {
+		if shares[i].Name == name {
+			return &shares[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// createSMBShare creates the SMB share of the filesystem for the node if it does not exist yet
+func (s *service) createSMBShare(ctx context.Context, systemID string, fs *siotypes.FileSystem, nodeID string) (*smbShare, error) {
+	share, err := s.getSMBShare(ctx, systemID, fs, nodeID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error getting the SMB share for the fs: %s", err.Error())
+	}
+	if share != nil {
+		log.Debugf("SMB share %s already exists for fs: %s", share.Name, fs.Name)
+		return share, nil
+	}
+
+	log.Debugf("SMB share does not exist for fs: %s on node %s, proceeding to create SMB share", fs.Name, nodeID)
+	share = &smbShare{
+		Name:         smbShareName(fs.Name, nodeID),
+		FileSystemID: fs.ID,
+		Path:         NFSExportLocalPath + fs.Name,
+		Description:  "Created by csi-powerflex driver for node " + nodeID,
+	}
+	created := &smbShare{}
+	if err := s.arrayRESTRequest(ctx, systemID, http.MethodPost, smbSharesURI, share, created); err != nil {
+		return nil, status.Errorf(codes.Internal, "create SMB share failed. Error:%v", err)
+	}
+	share.ID = created.ID
+	log.Infof("SMB share %s created for fs: %s", share.Name, fs.Name)
+	return share, nil
+}
+
+// deleteSMBShares removes all the SMB shares of the filesystem
+func (s *service) deleteSMBShares(ctx context.Context, systemID string, fs *siotypes.FileSystem) error {
+	shares, err := s.getSMBShares(ctx, systemID, fs)
+	if err != nil {
+		return err
+	}
+	for _, share := range shares {
+		log.Infof("Deleting SMB share %s of fs: %s", share.Name, fs.Name)
+		if err := s.arrayRESTRequest(ctx, systemID, http.MethodDelete, smbSharesURI+"/"+url.PathEscape(share.ID), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishSMBShare makes sure the filesystem has an SMB share for the node and passes its UNC path
+// to the node
+func (s *service) publishSMBShare(ctx context.Context, systemID string, fs *siotypes.FileSystem, nodeID string, client *goscaleio.Client, pContext map[string]string) (*csi.ControllerPublishVolumeResponse, error) {
+	share, err := s.createSMBShare(ctx, systemID, fs, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInterface, err := s.getFileInterface(systemID, fs, client)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error getting the file interface for the fs: %s", err.Error())
+	}
+
+	pContext[KeySMBShare] = smbShareUNC(fileInterface.IPAddress, share.Name)
+	return &csi.ControllerPublishVolumeResponse{PublishContext: pContext}, nil
+}
+
+// unpublishSMBShare removes the SMB share of the filesystem for the node, revoking its access.
+// It returns false when the node has no share, i.e. the volume is not an SMB volume or is already
+// unpublished from the node. A failed lookup of the shares, e.g. on an array without SMB, is
+// treated as no share.
+func (s *service) unpublishSMBShare(ctx context.Context, systemID string, fs *siotypes.FileSystem, nodeID string) (bool, error) {
+	share, err := s.getSMBShare(ctx, systemID, fs, nodeID)
+	if err != nil {
+		log.Warnf("unable to get the SMB share of fs: %s for node %s, unpublishing it as an NFS volume: %s", fs.Name, nodeID, err.Error())
+		return false, nil
+	}
+	if share == nil {
+		return false, nil
+	}
+	log.Infof("Deleting SMB share %s of fs: %s for node %s", share.Name, fs.Name, nodeID)
+	if err := s.arrayRESTRequest(ctx, systemID, http.MethodDelete, smbSharesURI+"/"+url.PathEscape(share.ID), nil, nil); err != nil {
+		return false, status.Errorf(codes.Internal, "delete SMB share %s failed. Error:%v", share.Name, err)
+	}
+	return true, nil
+}
+
+// smbMountShareName returns the name of the SMB share mounted at a volume path, i.e. csishare-volume
+// for //10.1.1.1/csishare-volume, or "" when it is not an SMB mount
+func smbMountShareName(mounts []gofsutil.Info) string {
+	for _, m := range mounts {
+		if m.Type == SMBFsType {
+			return path.Base(strings.TrimRight(m.Device, "/"))
+		}
+	}
+	return ""
+}
+
+// smbShareUNC formats the UNC path used to mount an SMB share, i.e. //10.1.1.1/csishare-volume
+func smbShareUNC(ipAddress, shareName string) string {
+	return fmt.Sprintf("//%s/%s", ipAddress, shareName)
+}
+
+// smbCredentials returns the contents of a mount.cifs credentials file built from the node publish secret
+func smbCredentials(secrets map[string]string) (string, error) {
+	username := secrets[smbSecretUsername]
+	password := secrets[smbSecretPassword]
+	if username == "" || password == "" {
+		return "", status.Errorf(codes.InvalidArgument,
+			"node publish secret must contain %s and %s for SMB volumes", smbSecretUsername, smbSecretPassword)
+	}
+	for _, v := range []string{username, password, secrets[smbSecretDomain]} {
+		if strings.ContainsAny(v, "\n\r") {
+			return "", status.Error(codes.InvalidArgument, "SMB credentials must not contain line breaks")
+		}
+	}
+
+	creds := fmt.Sprintf("username=%s\npassword=%s\n", username, password)
+	if domain := secrets[smbSecretDomain]; domain != "" {
+		creds += fmt.Sprintf("domain=%s\n", domain)
+	}
+	return creds, nil
+}
+
+// publishSMB mounts the SMB share to the target path, reading the credentials from the node publish secret
+func publishSMB(ctx context.Context, req *csi.NodePublishVolumeRequest, shareUNC string) error {
+	volCap := req.GetVolumeCapability()
+	if volCap == nil {
+		return status.Error(codes.InvalidArgument,
+			"Volume Capability is required")
+	}
+	mountVol := volCap.GetMount()
+	if mountVol == nil {
+		return status.Error(codes.InvalidArgument, "Invalid access type")
+	}
+	if shareUNC == "" {
+		return status.Errorf(codes.InvalidArgument, "%s is missing from the publish context", KeySMBShare)
+	}
+	target := req.GetTargetPath()
+	if target == "" {
+		return status.Error(codes.InvalidArgument,
+			"Target Path is required")
+	}
+
+	creds, err := smbCredentials(req.GetSecrets())
+	if err != nil {
+		return err
+	}
+
+	isMounted, err := isVolumeMounted(ctx, shareUNC, target)
+	if err != nil {
+		return err
+	}
+	if isMounted {
+		log.Debugf("SMB share %s already mounted at %s", shareUNC, target)
+		return nil
+	}
+
+	if _, err := mkdir(target); err != nil {
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("Could not create '%s': '%s'", target, err.Error()))
+	}
+
+	// the credentials file only lives for the duration of the mount call, in a directory only
+	// the driver can enter
+	credsDir, err := os.MkdirTemp("", "smb-creds-")
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not create SMB credentials directory: %s", err.Error())
+	}
+	defer os.RemoveAll(credsDir) // #nosec G104
+	if err := os.Chmod(credsDir, 0o700); err != nil {
+		return status.Errorf(codes.Internal, "could not secure SMB credentials directory: %s", err.Error())
+	}
+	credsFile := filepath.Join(credsDir, "credentials")
+	if err := os.WriteFile(credsFile, []byte(creds), 0o600); err != nil {
+		return status.Errorf(codes.Internal, "could not write SMB credentials file: %s", err.Error())
+	}
+
+	rwOption := "rw"
+	if req.GetReadonly() {
+		rwOption = "ro"
+	}
+	mntOptions := append(mountVol.GetMountFlags(), rwOption, "credentials="+credsFile)
+
+	log.WithFields(map[string]interface{}{
+		"ID":         req.VolumeId,
+		"TargetPath": target,
+		"Share":      shareUNC,
+	}).Info("Node publish SMB volume params ")
+
+	if err := gofsutil.Mount(ctx, shareUNC, target, SMBFsType, mntOptions...); err != nil {
+		return status.Errorf(codes.Internal, "error mounting SMB share %s: %s", shareUNC, err.Error())
+	}
+	return nil
+}
+
+// unpublishSMB unmounts the SMB share mounted at the target path and removes the target directory
+// publishSMB created. It returns false when no SMB share is mounted there.
+func unpublishSMB(ctx context.Context, target string) (bool, error) {
+	mnts, err := gofsutil.GetMounts(ctx)
+	if err != nil {
+		return false, status.Errorf(codes.Internal,
+			"could not reliably determine existing mount status: '%s'", err.Error())
+	}
+	mounted := false
+	for _, m := range mnts {
+		if m.Path == target && m.Type == SMBFsType {
+			mounted = true
+			break
+		}
+	}
+	if !mounted {
+		return false, nil
+	}
+
+	if err := gofsutil.Unmount(ctx, target); err != nil {
+		return false, status.Errorf(codes.Internal, "error unmounting SMB share at target '%s': '%s'", target, err.Error())
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return false, status.Errorf(codes.Internal, "error removing target '%s': '%s'", target, err.Error())
+	}
+	log.Debugf("unmounting SMB share at '%s' succeeded", target)
+	return true, nil
+}
@@ -0,0 +1,123 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dell/gofsutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsFileFsType(t *testing.T) {
+	assert.True(t, isFileFsType("nfs"))
+	assert.True(t, isFileFsType(SMBFsType))
+	assert.False(t, isFileFsType("ext4"))
+	assert.False(t, isFileFsType(""))
+}
+
+func TestGetFilesystemTopology(t *testing.T) {
+	s := &service{}
+	assert.Equal(t, map[string]string{Name + "/sys1-smb": "true"}, s.getFilesystemTopology("sys1", SMBFsType)[0].Segments)
+	assert.Equal(t, map[string]string{Name + "/sys1-nfs": "true"}, s.getFilesystemTopology("sys1", "nfs")[0].Segments)
+}
+
+func TestSmbShareUNC(t *testing.T) {
+	assert.Equal(t, "//10.1.1.1/csishare-vol1", smbShareUNC("10.1.1.1", SMBShareNamePrefix+"vol1"))
+}
+
+func TestSmbShareName(t *testing.T) {
+	name := smbShareName("vol1", "node1")
+	assert.Regexp(t, "^"+SMBShareNamePrefix+"vol1-[0-9a-f]{8}$", name)
+	assert.Equal(t, name, smbShareName("vol1", "node1"))
+	assert.NotEqual(t, name, smbShareName("vol1", "node2"))
+}
+
+func TestSmbMountShareName(t *testing.T) {
+	assert.Equal(t, "csishare-vol1", smbMountShareName([]gofsutil.Info{{Device: "//10.1.1.1/csishare-vol1", Type: SMBFsType}}))
+	assert.Equal(t, "", smbMountShareName([]gofsutil.Info{{Device: "10.1.1.1:/vol1", Type: "nfs4"}}))
+	assert.Equal(t, "", smbMountShareName(nil))
+}
+
+func TestUnpublishSMB(t *testing.T) {
+	gofsutil.UseMockFS()
+	defer func() { gofsutil.GOFSMockMounts = gofsutil.GOFSMockMounts[:0] }()
+	ctx := context.Background()
+	target := filepath.Join(t.TempDir(), "mount")
+	assert.NoError(t, os.Mkdir(target, 0o750))
+
+	// an NFS mount is left to the NFS unpublish
+	gofsutil.GOFSMockMounts = []gofsutil.Info{{Device: "10.1.1.1:/vol1", Path: target, Type: "nfs"}}
+	unpublished, err := unpublishSMB(ctx, target)
+	assert.NoError(t, err)
+	assert.False(t, unpublished)
+	assert.Len(t, gofsutil.GOFSMockMounts, 1)
+
+	gofsutil.GOFSMockMounts = []gofsutil.Info{{Device: "//10.1.1.1/" + smbShareName("vol1", "node1"), Path: target, Type: SMBFsType}}
+	unpublished, err = unpublishSMB(ctx, target)
+	assert.NoError(t, err)
+	assert.True(t, unpublished)
+	assert.Empty(t, gofsutil.GOFSMockMounts)
+	assert.NoDirExists(t, target)
+
+	// nothing left to do on retry
+	unpublished, err = unpublishSMB(ctx, target)
+	assert.NoError(t, err)
+	assert.False(t, unpublished)
+}
+
+func TestSmbCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "user and password",
+			secrets: map[string]string{"username": "user", "password": "pass"},
+			want:    "username=user\npassword=pass\n",
+		},
+		{
+			name:    "with domain",
+			secrets: map[string]string{"username": "user", "password": "pass", "domain": "CORP"},
+			want:    "username=user\npassword=pass\ndomain=CORP\n",
+		},
+		{
+			name:    "missing password",
+			secrets: map[string]string{"username": "user"},
+			wantErr: true,
+		},
+		{
+			name:    "line break",
+			secrets: map[string]string{"username": "user\nfoo", "password": "pass"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := smbCredentials(tt.secrets)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dell/goscaleio"
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Snapshot access mode limits accepted in snapshotAccessMode
+const (
+	snapshotAccessReadOnly  = "ReadOnly"
+	snapshotAccessReadWrite = "ReadWrite"
+)
+
+// getSnapshotProtection returns the access mode limit and secure snapshot retention, in minutes,
+// requested in the snapshot parameters. Snapshots are read only unless asked otherwise, and
+// secure snapshots must stay read only.
+func getSnapshotProtection(params map[string]string) (string, string, error) {
+	accessMode := snapshotAccessReadOnly
+	switch mode := params[KeySnapshotAccessMode]; {
+	case mode == "":
+	case strings.EqualFold(mode, snapshotAccessReadOnly):
+	case strings.EqualFold(mode, snapshotAccessReadWrite):
+		accessMode = snapshotAccessReadWrite
+	default:
+		return "", "", status.Errorf(codes.InvalidArgument, "invalid %s: %s, allowed values are %s and %s",
+			KeySnapshotAccessMode, mode, snapshotAccessReadOnly, snapshotAccessReadWrite)
+	}
+
+	retention := strings.TrimSpace(params[KeySnapshotRetentionInMin])
+	if retention == "" {
+		return accessMode, "", nil
+	}
+	minutes, err := strconv.Atoi(retention)
+	if err != nil || minutes <= 0 {
+		return "", "", status.Errorf(codes.InvalidArgument, "invalid %s: %s, must be a positive number of minutes",
+			KeySnapshotRetentionInMin, retention)
+	}
+	if accessMode != snapshotAccessReadOnly {
+		return "", "", status.Errorf(codes.InvalidArgument, "secure snapshots must be %s, %s can not be %s",
+			snapshotAccessReadOnly, KeySnapshotAccessMode, accessMode)
+	}
+	return accessMode, strconv.Itoa(minutes), nil
+}
+
+// secureSnapshotExpiry returns when the retention of a secure snapshot ends, and whether it is still retained
+func secureSnapshotExpiry(vol *siotypes.Volume) (time.Time, bool) {
+	if vol.SecureSnapshotExpTime <= 0 {
+		return time.Time{}, false
+	}
+	expiry := time.Unix(int64(vol.SecureSnapshotExpTime), 0)
+	return expiry, time.Now().Before(expiry)
+}
+
+// SecureSnapshotExpiryHeader is the gRPC response header of ListSnapshots telling when the retention
+// of the listed secure snapshots ends, with one "<snapshot ID>=<RFC 3339 time>" value per retained
+// snapshot, as CSI snapshot entries have no field for it
+const SecureSnapshotExpiryHeader = "csi-vxflexos-secure-snapshot-expiry"
+
+// secureSnapshotExpiryValue formats the SecureSnapshotExpiryHeader value of a retained snapshot
+func secureSnapshotExpiryValue(snapshotID string, expiry time.Time) string {
+	return snapshotID + "=" + expiry.UTC().Format(time.RFC3339)
+}
+
+// setSecureSnapshotExpiryHeader adds the expiry of the retained snapshots to the response headers
+func setSecureSnapshotExpiryHeader(ctx context.Context, expiries []string) {
+	if len(expiries) == 0 {
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.MD{SecureSnapshotExpiryHeader: expiries}); err != nil {
+		log.Warnf("unable to set the %s header: %s", SecureSnapshotExpiryHeader, err.Error())
+	}
+}
+
+// snapshotPolicyDetach keeps the snapshots taken by a snapshot policy when its source volume is unassigned
+const snapshotPolicyDetach = "Detach"
+
+// getSnapshotPolicy returns the snapshot policy with the given name
+func (s *service) getSnapshotPolicy(systemID string, name string) (*siotypes.SnapshotPolicy, error) {
+	policies, err := s.systems[systemID].GetSnapshotPolicy(name, "")
+	if err != nil || len(policies) == 0 {
+		return nil, status.Errorf(codes.NotFound, "snapshot policy %s not found on system %s: %v", name, systemID, err)
+	}
+	return &policies[0], nil
+}
+
+// assignSnapshotPolicy assigns the volume to the snapshot policy as a source volume, if not done already
+func (s *service) assignSnapshotPolicy(systemID string, csiVolID string, policy *siotypes.SnapshotPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	volID := getVolumeIDFromCsiVolumeID(csiVolID)
+	vol, err := s.getVolByID(volID, systemID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failure checking volume status before assigning snapshot policy: %s", err.Error())
+	}
+	switch vol.SnplIDOfSourceVolume {
+	case policy.ID:
+		log.Debugf("volume %s is already assigned to snapshot policy %s", volID, policy.Name)
+		return nil
+	case "":
+	default:
+		return status.Errorf(codes.AlreadyExists, "volume %s is assigned to snapshot policy %s, not %s",
+			volID, vol.SnplIDOfSourceVolume, policy.Name)
+	}
+
+	err = s.systems[systemID].AssignVolumeToSnapshotPolicy(&siotypes.AssignVolumeToSnapshotPolicyParam{
+		SourceVolumeId: volID,
+	}, policy.ID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error assigning volume %s to snapshot policy %s: %s", volID, policy.Name, err.Error())
+	}
+	log.Infof("Volume %s assigned to snapshot policy %s", volID, policy.Name)
+	return nil
+}
+
+// unassignSnapshotPolicy removes the volume from its snapshot policy, if it has one
+func (s *service) unassignSnapshotPolicy(systemID string, vol *siotypes.Volume) error {
+	if vol.SnplIDOfSourceVolume == "" {
+		return nil
+	}
+	err := s.systems[systemID].UnassignVolumeFromSnapshotPolicy(&siotypes.AssignVolumeToSnapshotPolicyParam{
+		SourceVolumeId:            vol.ID,
+		AutoSnapshotRemovalAction: snapshotPolicyDetach,
+	}, vol.SnplIDOfSourceVolume)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error unassigning volume %s from snapshot policy %s: %s",
+			vol.ID, vol.SnplIDOfSourceVolume, err.Error())
+	}
+	log.Infof("Volume %s unassigned from snapshot policy %s", vol.ID, vol.SnplIDOfSourceVolume)
+	return nil
+}
+
+// maxSnapshotsPerVTree is the number of snapshots PowerFlex allows in the volume tree of a volume
+const maxSnapshotsPerVTree = 126
+
+// driverSnapshotName matches the snapshots taken by CreateSnapshot for VolumeSnapshots,
+// whose "snapshot-<uid>" names are shortened to "sn-<uid>"
+var driverSnapshotName = regexp.MustCompile(`^sn-[0-9a-f-]+$`)
+
+// ensureVTreeSnapshotCapacity checks that a snapshot of every source volume fits in its VTree.
+// With prune set, the oldest snapshots taken by the driver are removed to make room.
+func (s *service) ensureVTreeSnapshotCapacity(systemID string, sources []*siotypes.Volume, prune bool) error {
+	needed := make(map[string]int)
+	sourceNames := make(map[string]string)
+	for _, vol := range sources {
+		needed[vol.VTreeID]++
+		sourceNames[vol.VTreeID] = vol.Name
+	}
+
+	allSnaps, err := getVolumeFunc(s.adminClients[systemID], "", "", "", "", true)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failure listing snapshots: %s", err.Error())
+	}
+
+	for vtreeID, count := range needed {
+		vtreeSnaps := make([]*siotypes.Volume, 0)
+		for _, snap := range allSnaps {
+			if snap.VTreeID == vtreeID {
+				vtreeSnaps = append(vtreeSnaps, snap)
+			}
+		}
+		excess := len(vtreeSnaps) + count - maxSnapshotsPerVTree
+		if excess <= 0 {
+			continue
+		}
+		if !prune {
+			return status.Errorf(codes.ResourceExhausted,
+				"volume %s already has %d snapshots, the PowerFlex limit is %d per volume tree; delete snapshots or set %s in the VolumeSnapshotClass",
+				sourceNames[vtreeID], len(vtreeSnaps), maxSnapshotsPerVTree, KeyPruneSnapshots)
+		}
+
+		candidates := prunableSnapshots(vtreeSnaps)
+		if len(candidates) < excess {
+			return status.Errorf(codes.ResourceExhausted,
+				"volume %s already has %d snapshots, the PowerFlex limit is %d per volume tree, and only %d can be pruned",
+				sourceNames[vtreeID], len(vtreeSnaps), maxSnapshotsPerVTree, len(candidates))
+		}
+		for _, snap := range candidates[:excess] {
+			log.Infof("Pruning snapshot %s (%s) of volume %s to stay within the volume tree limit", snap.Name, snap.ID, sourceNames[vtreeID])
+			tgtVol := goscaleio.NewVolume(s.adminClients[systemID])
+			tgtVol.Volume = snap
+			if err := tgtVol.RemoveVolume(removeModeOnlyMe); err != nil {
+				return status.Errorf(codes.Internal, "error pruning snapshot %s: %s", snap.ID, err.Error())
+			}
+		}
+		s.clearCache()
+	}
+	return nil
+}
+
+// prunableSnapshots returns, oldest first, the snapshots of a VTree taken by the driver that are
+// not mapped, not retained, and not the source of another snapshot or clone
+func prunableSnapshots(vtreeSnaps []*siotypes.Volume) []*siotypes.Volume {
+	referenced := make(map[string]bool)
+	for _, snap := range vtreeSnaps {
+		referenced[snap.AncestorVolumeID] = true
+	}
+
+	candidates := make([]*siotypes.Volume, 0)
+	for _, snap := range vtreeSnaps {
+		if !driverSnapshotName.MatchString(snap.Name) || referenced[snap.ID] || len(snap.MappedSdcInfo) > 0 {
+			continue
+		}
+		if _, retained := secureSnapshotExpiry(snap); retained {
+			continue
+		}
+		candidates = append(candidates, snap)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].CreationTime < candidates[j].CreationTime
+	})
+	return candidates
+}
@@ -0,0 +1,148 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGetSnapshotProtection(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        map[string]string
+		wantMode      string
+		wantRetention string
+		wantErr       bool
+	}{
+		{name: "defaults", params: map[string]string{}, wantMode: "ReadOnly"},
+		{name: "read write", params: map[string]string{KeySnapshotAccessMode: "readwrite"}, wantMode: "ReadWrite"},
+		{name: "secure", params: map[string]string{KeySnapshotRetentionInMin: "1440"}, wantMode: "ReadOnly", wantRetention: "1440"},
+		{name: "invalid mode", params: map[string]string{KeySnapshotAccessMode: "WriteOnly"}, wantErr: true},
+		{name: "invalid retention", params: map[string]string{KeySnapshotRetentionInMin: "1d"}, wantErr: true},
+		{name: "negative retention", params: map[string]string{KeySnapshotRetentionInMin: "-5"}, wantErr: true},
+		{
+			name:    "secure read write",
+			params:  map[string]string{KeySnapshotAccessMode: "ReadWrite", KeySnapshotRetentionInMin: "60"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, retention, err := getSnapshotProtection(tt.params)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMode, mode)
+			assert.Equal(t, tt.wantRetention, retention)
+		})
+	}
+}
+
+func TestSecureSnapshotExpiry(t *testing.T) {
+	snapWithExpiry := func(expTime time.Time) *siotypes.Volume {
+		return &siotypes.Volume{SecureSnapshotExpTime: int(expTime.Unix())}
+	}
+
+	_, retained := secureSnapshotExpiry(&siotypes.Volume{})
+	assert.False(t, retained)
+
+	_, retained = secureSnapshotExpiry(snapWithExpiry(time.Now().Add(-time.Hour)))
+	assert.False(t, retained)
+
+	expiry, retained := secureSnapshotExpiry(snapWithExpiry(time.Now().Add(time.Hour)))
+	assert.True(t, retained)
+	assert.True(t, expiry.After(time.Now()))
+}
+
+// headerStream captures the headers set by a gRPC handler
+type headerStream struct {
+	header metadata.MD
+}
+
+func (h *headerStream) Method() string { return "" }
+
+func (h *headerStream) SetHeader(md metadata.MD) error {
+	h.header = metadata.Join(h.header, md)
+	return nil
+}
+
+func (h *headerStream) SendHeader(md metadata.MD) error { return h.SetHeader(md) }
+
+func (h *headerStream) SetTrailer(_ metadata.MD) error { return nil }
+
+func TestSecureSnapshotExpiryHeader(t *testing.T) {
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	expiry := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	setSecureSnapshotExpiryHeader(ctx, nil)
+	assert.Empty(t, stream.header)
+
+	setSecureSnapshotExpiryHeader(ctx, []string{
+		secureSnapshotExpiryValue("sys1-snap1", expiry),
+		secureSnapshotExpiryValue("sys1-snap2", expiry.Add(time.Hour)),
+	})
+	assert.Equal(t, []string{"sys1-snap1=2026-10-18T12:00:00Z", "sys1-snap2=2026-10-18T13:00:00Z"},
+		stream.header.Get(SecureSnapshotExpiryHeader))
+
+	// no stream outside of a gRPC call, the listing still succeeds
+	setSecureSnapshotExpiryHeader(context.Background(), []string{secureSnapshotExpiryValue("sys1-snap1", expiry)})
+}
+
+func TestGetListSnapshotsEntries(t *testing.T) {
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	retainedUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	s := &service{}
+	entries := s.getListSnapshotsEntries(ctx, []*siotypes.Volume{
+		{ID: "snap1", AncestorVolumeID: "vol1"},
+		{ID: "snap2", AncestorVolumeID: "vol1", SecureSnapshotExpTime: int(retainedUntil.Unix())},
+		{ID: "snap3", AncestorVolumeID: "vol1", SecureSnapshotExpTime: int(time.Now().Add(-time.Hour).Unix())},
+	}, "sys1")
+
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "sys1-snap2", entries[1].Snapshot.SnapshotId)
+	assert.Equal(t, []string{"sys1-snap2=" + retainedUntil.UTC().Format(time.RFC3339)},
+		stream.header.Get(SecureSnapshotExpiryHeader))
+}
+
+func TestPrunableSnapshots(t *testing.T) {
+	vtreeSnaps := []*siotypes.Volume{
+		{ID: "s3", Name: "sn-33333333-3333-3333-3333-3333", AncestorVolumeID: "vol", CreationTime: 300},
+		{ID: "s1", Name: "sn-11111111-1111-1111-1111-1111", AncestorVolumeID: "vol", CreationTime: 100},
+		// source of a clone
+		{ID: "s2", Name: "sn-22222222-2222-2222-2222-2222", AncestorVolumeID: "vol", CreationTime: 200},
+		{ID: "c1", Name: "k8s-clone", AncestorVolumeID: "s2", CreationTime: 250},
+		// taken by another tool
+		{ID: "s0", Name: "nightly-backup", AncestorVolumeID: "vol", CreationTime: 50},
+	}
+
+	candidates := prunableSnapshots(vtreeSnaps)
+	ids := make([]string, 0, len(candidates))
+	for _, snap := range candidates {
+		ids = append(ids, snap.ID)
+	}
+	assert.Equal(t, []string{"s1", "s3"}, ids)
+}
@@ -25,6 +25,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/dell/csmlog"
@@ -42,6 +45,12 @@ const (
 	defaultDirPerm    = 0o700
 )
 
+// lazyUnmount detaches the mount at the path even when it is busy or its device is gone. Variable
+// so tests can fake it.
+var lazyUnmount = func(path string) error {
+	return syscall.Unmount(path, syscall.MNT_DETACH)
+}
+
 // StageStatus represents the staging readiness of the volume at stagingPath.
 type StageStatus int
 
@@ -83,11 +92,14 @@ type VolumeStager interface {
 
 // NVMeStager implementation for staging volumes to NVMe hosts.
 type NVMeStager struct {
-	useNVME       bool
-	nvmeConnector NVMEConnector
-	systemID      string
-	adminClient   *goscaleio.Client
-	targetNqn     map[string]string
+	useNVME        bool
+	nvmeConnector  NVMEConnector
+	systemID       string
+	adminClient    *goscaleio.Client
+	targetNqn      map[string]string
+	portalFilter   nvmePortalFilter
+	repairs        *sync.Map // map[StageStatus]*atomic.Int64, staging states repaired, nil when repairs are disabled
+	luksPassphrase []byte    // passphrase of the LUKS device of an encrypted volume, nil for other volumes
 }
 
 // Stage stages volume by connecting it through NVMe/TCP and creating bind mount to staging path.
@@ -125,6 +137,11 @@ func (n *NVMeStager) Stage(ctx context.Context, req *csi.NodeStageVolumeRequest,
 		return nil, status.Errorf(codes.Internal, "unable to get NVMe/TCP targets: %s", err.Error())
 	}
 
+	targetPortals = n.portalFilter.filterPortals(targetPortals)
+	if len(targetPortals) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "no NVMe/TCP target of system %s is allowed by the portal filters", n.systemID)
+	}
+
 	nvmeTargets := buildNVMeTargetInfo(n.targetNqn, targetPortals)
 
 	deviceInfo := deviceInfo{
@@ -145,7 +162,7 @@ func (n *NVMeStager) Stage(ctx context.Context, req *csi.NodeStageVolumeRequest,
 	// Check staging status
 	stageStatus, err := isAlreadyStaged(ctx, stagingPath)
 	log.WithFields(logFields).Debugf("staging status detected: %s", stageStatus.String())
-	if err != nil {
+	if err != nil && (n.repairs == nil || stageStatus != StageProbeError) {
 		return nil, status.Errorf(codes.Internal, "failed to probe staging state: %v", err)
 	}
 
@@ -153,7 +170,13 @@ func (n *NVMeStager) Stage(ctx context.Context, req *csi.NodeStageVolumeRequest,
 	case StageReady:
 		log.WithFields(logFields).Info("device already staged")
 		return &csi.NodeStageVolumeResponse{}, nil
-	case StageDeletedLink, StageMpathMember:
+	case StageDeletedLink, StageMpathMember, StageProbeError:
+		if n.repairs != nil {
+			if err := n.repairStagingPath(ctx, stagingPath, stageStatus, logFields); err != nil {
+				return nil, err
+			}
+			break
+		}
 		log.WithFields(logFields).Warnf("unsafe staging state (%s); performing cleanup", stageStatus)
 		if _, err := n.Unstage(ctx, stagingPath, logFields, volID); err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to cleanup staging path: %v", err)
@@ -171,6 +194,15 @@ func (n *NVMeStager) Stage(ctx context.Context, req *csi.NodeStageVolumeRequest,
 	}
 	logFields["DevicePath"] = devicePath
 
+	// Open the LUKS device of an encrypted volume, the filesystem is created on it
+	if n.luksPassphrase != nil {
+		devicePath, err = luksOpen(ctx, req.GetVolumeId(), devicePath, n.luksPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		logFields["LUKSDevicePath"] = devicePath
+	}
+
 	// Validate block device
 	sysDevice, err := GetDevice(devicePath)
 	if err != nil {
@@ -185,7 +217,11 @@ func (n *NVMeStager) Stage(ctx context.Context, req *csi.NodeStageVolumeRequest,
 		if fs == "xfs" {
 			mntFlags = append(mntFlags, "nouuid")
 		}
-		if err := handlePrivFSMount(ctx, accMode, sysDevice, mntFlags, fs, stagingPath, fsFormatOption); err != nil {
+		allowFormatOverwrite := strings.EqualFold(req.GetVolumeContext()[KeyAllowFormatOverwrite], "true")
+		if err := handlePrivFSMount(ctx, accMode, sysDevice, mntFlags, fs, stagingPath, fsFormatOption, allowFormatOverwrite); err != nil {
+			if status.Code(err) == codes.FailedPrecondition {
+				return nil, err
+			}
 			return nil, status.Errorf(codes.Internal, "failed to mount disk %s to staging path: %s", devicePath, err.Error())
 		}
 	}
@@ -194,6 +230,70 @@ func (n *NVMeStager) Stage(ctx context.Context, req *csi.NodeStageVolumeRequest,
 	return &csi.NodeStageVolumeResponse{}, nil
 }
 
+// repairStagingPath removes the mount left at the staging path in an inconsistent state, so the
+// volume is staged again: the device of a deleted link is mounted again, a multipath member is
+// replaced by the multipath device, and a mount that can't be probed is an orphaned bind mount.
+// A busy mount is only detached when no target path uses it, otherwise the volume would end up
+// mounted twice. The NVMe device stays connected, as the target paths of the volume may still use it.
+func (n *NVMeStager) repairStagingPath(ctx context.Context, stagingPath string, stageStatus StageStatus, logFields csmlog.Fields) error {
+	log := log.WithContext(ctx)
+	mounts, err := getPathMounts(ctx, stagingPath)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to probe staging state: %v", err)
+	}
+	if len(mounts) == 0 {
+		log.WithFields(logFields).Infof("%s staging state is gone, nothing is mounted at the staging path", stageStatus)
+		return nil
+	}
+	for range mounts {
+		if stageStatus == StageProbeError {
+			err = lazyUnmount(stagingPath)
+		} else if err = gofsutil.Unmount(ctx, stagingPath); err != nil {
+			// staging again while target paths still use the old mount would mount the volume twice
+			targets, targetErr := stagingPathTargets(ctx, stagingPath, mounts)
+			if targetErr != nil {
+				return status.Errorf(codes.Internal, "failed to probe staging state: %v", targetErr)
+			}
+			if len(targets) > 0 {
+				return status.Errorf(codes.Internal, "failed to repair %s staging state of %s: %s, target paths %v still use it",
+					stageStatus, stagingPath, err.Error(), targets)
+			}
+			log.WithFields(logFields).Warnf("unmounting staging path failed (%s) and no target path uses it, detaching it", err.Error())
+			err = lazyUnmount(stagingPath)
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to repair %s staging state of %s: %s", stageStatus, stagingPath, err.Error())
+		}
+	}
+
+	counter, _ := n.repairs.LoadOrStore(stageStatus, &atomic.Int64{})
+	counter.(*atomic.Int64).Add(1)
+	log.WithFields(logFields).Warnf("repaired %s staging state by removing %d mounts, staging again", stageStatus, len(mounts))
+	return nil
+}
+
+// stagingPathTargets returns the other paths the devices mounted at the staging path are mounted at,
+// these are the target paths of the pods using the volume.
+func stagingPathTargets(ctx context.Context, stagingPath string, stagingMounts []gofsutil.Info) ([]string, error) {
+	mounts, err := gofsutil.GetMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, m := range mounts {
+		if m.Path == stagingPath {
+			continue
+		}
+		for _, sm := range stagingMounts {
+			if m.Device == sm.Device {
+				targets = append(targets, m.Path)
+				break
+			}
+		}
+	}
+	return targets, nil
+}
+
 func (n *NVMeStager) Unstage(ctx context.Context, stagingPath string, logFields csmlog.Fields, volID string) (*csi.NodeUnstageVolumeResponse, error) {
 	log := log.WithContext(ctx)
 
@@ -215,6 +315,9 @@ func (n *NVMeStager) Unstage(ctx context.Context, stagingPath string, logFields
 	log.WithFields(logFields).Info("unmounting directory")
 	if err := gofsutil.Unmount(ctx, stagingPath); err != nil && !os.IsNotExist(err) {
 		log.Errorf("Unable to Unmount staging target path: %s", err)
+		if isBusyError(err) {
+			return nil, status.Errorf(codes.Internal, "Unable to unmount staging target path %s: %s", stagingPath, err.Error())
+		}
 	}
 
 	log.WithFields(logFields).Info("removing directory")
@@ -222,6 +325,20 @@ func (n *NVMeStager) Unstage(ctx context.Context, stagingPath string, logFields
 		log.Errorf("Unable to remove staging target path: %v", err)
 	}
 
+	// Close the LUKS device of an encrypted volume, the NVMe device is the one under it
+	if luksDevicePath(volID) != "" {
+		backingDevice, err := luksBackingDevice(ctx, volID)
+		if err != nil {
+			log.Errorf("NodeUnstageVolume: %v", err)
+		}
+		if err := luksClose(ctx, volID); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if devicePath != "" && backingDevice != "" {
+			devicePath = backingDevice
+		}
+	}
+
 	// If we found a backing device and it looks like NVME, disconnect it.
 	if devicePath != "" {
 		log.Infof("NodeUnsatgeVolume: disconnecting NVME device %s for volumeID= %s", devicePath, volID)
@@ -0,0 +1,78 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dell/csmlog"
+	"github.com/dell/gofsutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepairStagingPath(t *testing.T) {
+	defer func(f func(string) error) { lazyUnmount = f }(lazyUnmount)
+	gofsutil.UseMockFS()
+	defer func() {
+		gofsutil.GOFSMockMounts = gofsutil.GOFSMockMounts[:0]
+		gofsutil.GOFSMock.InduceUnmountError = false
+	}()
+
+	var detached []string
+	lazyUnmount = func(path string) error {
+		detached = append(detached, path)
+		gofsutil.GOFSMockMounts = gofsutil.GOFSMockMounts[:0]
+		return nil
+	}
+	s := &service{}
+	stager := &NVMeStager{repairs: &s.nvmeStageRepairs}
+	stagingPath := t.TempDir()
+	mount := gofsutil.Info{Device: "/dev/nvme0n1", Path: stagingPath, Type: "ext4"}
+
+	// nothing is mounted at the staging path, there is nothing to repair
+	assert.NoError(t, stager.repairStagingPath(context.Background(), stagingPath, StageDeletedLink, csmlog.Fields{}))
+	assert.Empty(t, detached)
+
+	// a busy multipath member still used by a target path is not detached and staged again
+	target := gofsutil.Info{Device: mount.Device, Path: "/var/lib/kubelet/pods/pod1/volumes/vol1/mount", Type: "ext4"}
+	gofsutil.GOFSMockMounts = []gofsutil.Info{mount, target}
+	gofsutil.GOFSMock.InduceUnmountError = true
+	err := stager.repairStagingPath(context.Background(), stagingPath, StageMpathMember, csmlog.Fields{})
+	assert.ErrorContains(t, err, target.Path)
+	assert.Empty(t, detached)
+
+	// the mount of a deleted link is detached when it can't be unmounted and no target path uses it
+	gofsutil.GOFSMockMounts = []gofsutil.Info{mount}
+	assert.NoError(t, stager.repairStagingPath(context.Background(), stagingPath, StageDeletedLink, csmlog.Fields{}))
+	assert.Equal(t, []string{stagingPath}, detached)
+	gofsutil.GOFSMock.InduceUnmountError = false
+
+	// the mount of a multipath member is unmounted
+	detached = nil
+	gofsutil.GOFSMockMounts = []gofsutil.Info{mount}
+	assert.NoError(t, stager.repairStagingPath(context.Background(), stagingPath, StageMpathMember, csmlog.Fields{}))
+	assert.Empty(t, detached)
+	assert.Empty(t, gofsutil.GOFSMockMounts)
+
+	// a mount that can't be probed is detached right away
+	gofsutil.GOFSMockMounts = []gofsutil.Info{mount}
+	assert.NoError(t, stager.repairStagingPath(context.Background(), stagingPath, StageProbeError, csmlog.Fields{}))
+	assert.Equal(t, []string{stagingPath}, detached)
+
+	metrics := s.formatNVMePathMetrics()
+	assert.Contains(t, metrics, `powerflex_nvme_stage_repairs_total{state="probe_error"} 1`)
+	assert.Contains(t, metrics, `powerflex_nvme_stage_repairs_total{state="deleted_link"} 1`)
+	assert.Contains(t, metrics, `powerflex_nvme_stage_repairs_total{state="mpath_member"} 1`)
+}
@@ -344,6 +344,8 @@ func (f *feature) aVxFlexOSServiceWithTimeoutMilliseconds(millis int) error {
 	gofsutil.GOFSMockMounts = gofsutil.GOFSMockMounts[:0]
 	gofsutil.GOFSWWNPath = nodePublishSymlinkDir + "/nvme-eui."
 	clear(gofsutil.GOFSMockWWNToDevice)
+	// the mock devices are blank, there is nothing for blkid to probe
+	getDeviceSignature = func(_ context.Context, _ string) (deviceSignature, error) { return deviceSignature{}, nil }
 
 	// configure variables in the driver
 	publishGetMappedVolMaxRetry = 2
@@ -844,6 +846,25 @@ func (f *feature) iSpecifyCreateVolumeMountRequest(fstype string) error {
 	return nil
 }
 
+func (f *feature) iSpecifySnapshotPolicy(policy string) error {
+	if f.createVolumeRequest == nil {
+		f.createVolumeRequest = getTypicalCreateVolumeRequest()
+	}
+	f.createVolumeRequest.Parameters[KeySnapshotPolicy] = policy
+	return nil
+}
+
+func (f *feature) volumeIsAssignedToSnapshotPolicy(name, policy string) error {
+	want := ""
+	if policy == mockSnapshotPolicyName {
+		want = mockSnapshotPolicyID
+	}
+	if got := volumeIDToSnapshotPolicyID[volumeNameToID[name]]; got != want {
+		return fmt.Errorf("expected volume %s to be assigned to snapshot policy %q, got %q", name, want, got)
+	}
+	return nil
+}
+
 func (f *feature) iCallCreateVolume(name string) error {
 	ctx := context.Background()
 	if f.createVolumeRequest == nil {
@@ -1397,6 +1418,8 @@ func (f *feature) iInduceError(errtype string) error {
 		stepHandlersErrors.VolumeInstancesError = true
 	case "FileSystemInstancesError":
 		stepHandlersErrors.FileSystemInstancesError = true
+	case "SMBSharesError":
+		stepHandlersErrors.SMBSharesError = true
 	case "GetFileSystemsByIdError":
 		stepHandlersErrors.GetFileSystemsByIDError = true
 	case "NasNotFoundError":
@@ -2754,6 +2777,8 @@ func (f *feature) aValidControllerGetCapabilitiesResponseIsReturned() error {
 				count = count + 1
 			case csi.ControllerServiceCapability_RPC_CLONE_VOLUME:
 				count = count + 1
+			case csi.ControllerServiceCapability_RPC_MODIFY_VOLUME:
+				count = count + 1
 			case csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER:
 				count = count + 1
 			case csi.ControllerServiceCapability_RPC_GET_VOLUME:
@@ -2765,11 +2790,11 @@ func (f *feature) aValidControllerGetCapabilitiesResponseIsReturned() error {
 			}
 		}
 
-		if f.service.opts.IsHealthMonitorEnabled && count != 11 {
+		if f.service.opts.IsHealthMonitorEnabled && count != 12 {
 			// Set default value
 			f.service.opts.IsHealthMonitorEnabled = false
 			return errors.New("Did not retrieve all the expected capabilities")
-		} else if !f.service.opts.IsHealthMonitorEnabled && count != 9 {
+		} else if !f.service.opts.IsHealthMonitorEnabled && count != 10 {
 			return errors.New("Did not retrieve all the expected capabilities")
 		}
 
@@ -3464,7 +3489,7 @@ func (f *feature) iCallUnmountPrivMount() error {
 	}
 	/*
 		//  needs a mounted ok device to unmount
-		_ = handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "")
+		_ = handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "", false)
 
 		target := "/tmp/foo"
 		flags := make([]string, 0)
@@ -3505,7 +3530,7 @@ func (f *feature) iCallHandlePrivFSMount() error {
 		RealDev:  device,
 	}
 	fmt.Printf("debug input param sysDevice %#v\n", sysDevice)
-	err := handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "")
+	err := handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "", false)
 	msg := "mount induced error"
 	fmt.Printf("expected handlePrivFSMount error msg = %s\n", err.Error())
 	if err != nil && strings.Contains(err.Error(), msg) {
@@ -3514,7 +3539,7 @@ func (f *feature) iCallHandlePrivFSMount() error {
 	accessMode.Mode = csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER
 	gofsutil.GOFSMock.InduceMountError = false
 	gofsutil.GOFSMock.InduceBindMountError = true
-	err = handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "rw")
+	err = handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "rw", false)
 	msg = "bindMount induced error"
 	fmt.Printf("expected handlePrivFSMount error msg= %s\n", err.Error())
 	if err != nil && strings.Contains(err.Error(), msg) {
@@ -3524,7 +3549,7 @@ func (f *feature) iCallHandlePrivFSMount() error {
 	accessMode.Mode = csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER
 	gofsutil.GOFSMock.InduceMountError = false
 	gofsutil.GOFSMock.InduceBindMountError = false
-	err = handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "")
+	err = handlePrivFSMount(context.TODO(), accessMode, sysDevice, nil, "", "", "", false)
 	msg = "Invalid access mode"
 	fmt.Printf("expected handlePrivFSMount error msg= %s\n", err.Error())
 	if err != nil && strings.Contains(err.Error(), msg) {
@@ -4525,6 +4550,17 @@ func (f *feature) thereAreValidSnapshotsOfVolume(nsnapshots int, volume string)
 	return nil
 }
 
+func (f *feature) thereAreSnapshotsTakenBySnapshotPolicyOfVolume(nsnapshots int, volume string) error {
+	start := f.snapshotIndex
+	if err := f.thereAreValidSnapshotsOfVolume(nsnapshots, volume); err != nil {
+		return err
+	}
+	for i := start; i < f.snapshotIndex; i++ {
+		volumeIDToAutoSnapshotPolicyID[fmt.Sprintf(arrayID+"-%d", i)] = mockSnapshotPolicyID
+	}
+	return nil
+}
+
 func (f *feature) iCallListSnapshotsWithMaxentriesAndStartingtoken(maxEntriesString, startingTokenString string) error {
 	maxEntries, err := strconv.ParseInt(maxEntriesString, 10, 32)
 	if err != nil {
@@ -5406,10 +5442,15 @@ func (f *feature) iSpecifyExternalAccess(externalAccess string) error {
 	return nil
 }
 
-func (f *feature) iCallGetNASServerIDFromName(systemID string, name string) error {
-	id := ""
-	id, f.err = f.service.getNASServerIDFromName(systemID, name)
-	fmt.Printf("NAS server id for %s is : %s\n", name, id)
+func (f *feature) iCallGetNASServersFromNames(systemID string, names string) error {
+	system, err := f.service.adminClients[systemID].FindSystem(systemID, "", "")
+	if err != nil {
+		f.err = err
+		return nil
+	}
+	var nasServers []*types.NAS
+	nasServers, f.err = f.service.getNASServers(system, parseNasNames(names))
+	fmt.Printf("NAS servers for %s are : %v\n", names, nasServerNames(nasServers))
 	return nil
 }
 
@@ -5641,6 +5682,8 @@ func FeatureContext(s *godog.ScenarioContext) {
 	s.Step(`^I induce error "([^"]*)"$`, f.iInduceError)
 	s.Step(`^I specify VolumeContentSource$`, f.iSpecifyVolumeContentSource)
 	s.Step(`^I specify CreateVolumeMountRequest "([^"]*)"$`, f.iSpecifyCreateVolumeMountRequest)
+	s.Step(`^I specify snapshot policy "([^"]*)"$`, f.iSpecifySnapshotPolicy)
+	s.Step(`^volume "([^"]*)" is assigned to snapshot policy "([^"]*)"$`, f.volumeIsAssignedToSnapshotPolicy)
 	s.Step(`^I call PublishVolume with "([^"]*)"$`, f.iCallPublishVolumeWith)
 	s.Step(`^I call NFS PublishVolume with "([^"]*)"$`, f.iCallPublishVolumeWithNFS)
 	s.Step(`^a valid PublishVolumeResponse is returned$`, f.aValidPublishVolumeResponseIsReturned)
@@ -5745,6 +5788,7 @@ func FeatureContext(s *godog.ScenarioContext) {
 	s.Step(`^the wrong capacity$`, f.theWrongCapacity)
 	s.Step(`^the wrong storage pool$`, f.theWrongStoragePool)
 	s.Step(`^there are (\d+) valid snapshots of "([^"]*)" volume$`, f.thereAreValidSnapshotsOfVolume)
+	s.Step(`^there are (\d+) snapshots taken by snapshot policy of "([^"]*)" volume$`, f.thereAreSnapshotsTakenBySnapshotPolicyOfVolume)
 	s.Step(`^I call ListSnapshots with max_entries "([^"]*)" and starting_token "([^"]*)"$`, f.iCallListSnapshotsWithMaxentriesAndStartingtoken)
 	s.Step(`^a valid ListSnapshotsResponse is returned with listed "([^"]*)" and next_token "([^"]*)"$`, f.aValidListSnapshotsResponseIsReturnedWithListedAndNexttoken)
 	s.Step(`^the total snapshots listed is "([^"]*)"$`, f.theTotalSnapshotsListedIs)
@@ -5831,7 +5875,7 @@ func FeatureContext(s *godog.ScenarioContext) {
 	s.Step(`^I call externalAccessAlreadyAdded with externalAccess "([^"]*)"`, f.iCallexternalAccessAlreadyAdded)
 	s.Step(`^an NFSExport instance with nfsexporthost "([^"]*)"`, f.iCallGivenNFSExport)
 	s.Step(`^I specify External Access "([^"]*)"`, f.iSpecifyExternalAccess)
-	s.Step(`^I call Get NAS server from name "([^"]*)" "([^"]*)"$`, f.iCallGetNASServerIDFromName)
+	s.Step(`^I call Get NAS servers from names "([^"]*)" "([^"]*)"$`, f.iCallGetNASServersFromNames)
 	s.Step(`^I call check NFS enabled "([^"]*)"$`, f.iCallIsNFSEnabled)
 	s.Step(`^I call GetNodeUID$`, f.iCallGetNodeUID)
 	s.Step(`^a valid node uid is returned$`, f.aValidNodeUIDIsReturned)
@@ -69,6 +69,7 @@ var (
 		GetFileSystemsByIDError       bool
 		NoFileSystemIDError           bool
 		NFSExportInstancesError       bool
+		SMBSharesError                bool
 		NasServerNotFoundError        bool
 		FileInterfaceNotFoundError    bool
 		BadVolIDError                 bool
@@ -164,6 +165,8 @@ func getHandler() http.Handler {
 	volumeIDToConsistencyGroupID = make(map[string]string)
 	volumeIDToReplicationState = make(map[string]string)
 	volumeIDToSizeInKB = make(map[string]string)
+	volumeIDToSnapshotPolicyID = make(map[string]string)
+	volumeIDToAutoSnapshotPolicyID = make(map[string]string)
 	nfsExportIDReadOnlyRootHosts = make(map[string][]string)
 	nfsExportIDReadWriteRootHosts = make(map[string][]string)
 	nfsExportIDReadWriteHosts = make(map[string][]string)
@@ -202,6 +205,7 @@ func getHandler() http.Handler {
 	stepHandlersErrors.FileInterfaceNotFoundError = false
 	stepHandlersErrors.FileSystemInstancesError = false
 	stepHandlersErrors.NFSExportInstancesError = false
+	stepHandlersErrors.SMBSharesError = false
 	stepHandlersErrors.NasServerNotFoundError = false
 	stepHandlersErrors.BadCapacityError = false
 	stepHandlersErrors.BadVolIDError = false
@@ -290,6 +294,9 @@ func getRouter() http.Handler {
 	scaleioRouter.HandleFunc("/rest/v1/file-tree-quotas/{id}", handleGetFileTreeQuotas)
 	scaleioRouter.HandleFunc("/api/instances/System/action/querySystemLimits", handleGetSystemLimits)
 	scaleioRouter.HandleFunc("/rest/v1/nfs-servers", handleIsNFSEnabled)
+	scaleioRouter.HandleFunc("/rest/v1/smb-shares", handleSMBShares)
+	scaleioRouter.HandleFunc("/api/types/SnapshotPolicy/instances", handleSnapshotPolicyInstances)
+	scaleioRouter.HandleFunc("/rest/v1/smb-shares/{id}", handleSMBShares)
 	scaleioRouter.HandleFunc("/api/types/Sdt/instances", handleGetAllSdt)
 	scaleioRouter.HandleFunc("/dtapi/rest/v1/metrics/query", handleMetricsQuery)
 	return scaleioRouter
@@ -433,6 +440,32 @@ func handleNasInstances(w http.ResponseWriter, _ *http.Request) {
 	returnJSONFile("features", "get_nas_servers.json", w, nil)
 }
 
+// handleSMBShares implements GET, POST and DELETE rest/v1/smb-shares, the mock array has no SMB shares
+func handleSMBShares(w http.ResponseWriter, r *http.Request) {
+	if stepHandlersErrors.SMBSharesError {
+		writeError(w, "SMB is not enabled", http.StatusNotFound, codes.NotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Write([]byte("[]"))
+	case http.MethodPost:
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"smb-share-1"}`))
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleSnapshotPolicyInstances implements GET /api/types/SnapshotPolicy/instances
+func handleSnapshotPolicyInstances(w http.ResponseWriter, _ *http.Request) {
+	policies := []types.SnapshotPolicy{{ID: mockSnapshotPolicyID, Name: mockSnapshotPolicyName}}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(policies); err != nil {
+		log.Infof("error encoding json: %s\n", err)
+	}
+}
+
 // handleIsNFSEnabled implements GET rest/v1/nfs-servers?select=*
 func handleIsNFSEnabled(w http.ResponseWriter, _ *http.Request) {
 	if stepHandlersErrors.NoNfsServer {
@@ -1153,6 +1186,18 @@ var volumeIDToReplicationState map[string]string
 // Map of volume ID to size in KB
 var volumeIDToSizeInKB map[string]string
 
+// Map of volume ID to the ID of the snapshot policy the volume is a source volume of
+var volumeIDToSnapshotPolicyID map[string]string
+
+// Map of snapshot ID to the ID of the snapshot policy that took the snapshot
+var volumeIDToAutoSnapshotPolicyID map[string]string
+
+// Snapshot policy of the mock array
+const (
+	mockSnapshotPolicyID   = "5e2c5d7200000001"
+	mockSnapshotPolicyName = "daily-snapshots"
+)
+
 // Map of FileSystem ID to size Total
 var fileSystemIDToSizeTotal map[string]string
 
@@ -1211,6 +1256,8 @@ func handleVolumeInstances(w http.ResponseWriter, r *http.Request) {
 		volumeIDToConsistencyGroupID = make(map[string]string)
 		volumeIDToReplicationState = make(map[string]string)
 		volumeIDToSizeInKB = make(map[string]string)
+		volumeIDToSnapshotPolicyID = make(map[string]string)
+		volumeIDToAutoSnapshotPolicyID = make(map[string]string)
 	}
 
 	if stepHandlersErrors.VolumeInstancesError {
@@ -1301,6 +1348,8 @@ func handleVolumeInstances(w http.ResponseWriter, r *http.Request) {
 				replacementMap["__CONSISTENCY_GROUP_ID__"] = vol["consistencyGroupID"]
 				replacementMap["__SIZE_IN_KB__"] = vol["sizeInKb"]
 				replacementMap["__VOLUME_REPLICATION_STATE__"] = vol["volumeReplicationState"]
+				replacementMap["__SNAPSHOT_POLICY_ID__"] = volumeIDToSnapshotPolicyID[id]
+				replacementMap["__AUTO_SNAPSHOT_POLICY_ID__"] = volumeIDToAutoSnapshotPolicyID[id]
 				data := returnJSONFile("features", "volume.json.template", nil, replacementMap)
 				vol := new(types.Volume)
 				err := json.Unmarshal(data, vol)
@@ -1326,6 +1375,8 @@ func handleVolumeInstances(w http.ResponseWriter, r *http.Request) {
 			replacementMap["__CONSISTENCY_GROUP_ID__"] = volumeIDToConsistencyGroupID[id]
 			replacementMap["__SIZE_IN_KB__"] = volumeIDToSizeInKB[id]
 			replacementMap["__VOLUME_REPLICATION_STATE__"] = volumeIDToReplicationState[id]
+			replacementMap["__SNAPSHOT_POLICY_ID__"] = volumeIDToSnapshotPolicyID[id]
+			replacementMap["__AUTO_SNAPSHOT_POLICY_ID__"] = volumeIDToAutoSnapshotPolicyID[id]
 			data := returnJSONFile("features", "volume.json.template", nil, replacementMap)
 			vol := new(types.Volume)
 			err := json.Unmarshal(data, vol)
@@ -1373,6 +1424,19 @@ func handleAction(w http.ResponseWriter, r *http.Request) {
 
 		setSdcNameSuccess = true
 
+	case "addSourceVolumeToSnapshotPolicy", "removeSourceVolumeFromSnapshotPolicy":
+		req := types.AssignVolumeToSnapshotPolicyParam{}
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&req)
+		if err != nil {
+			log.Infof("error decoding json: %s\n", err.Error())
+		}
+		if action == "addSourceVolumeToSnapshotPolicy" {
+			volumeIDToSnapshotPolicyID[req.SourceVolumeId] = id
+		} else {
+			delete(volumeIDToSnapshotPolicyID, req.SourceVolumeId)
+		}
+
 	case "approveSdc":
 		errMsg := "The given GUID is invalid.Please specify GUID in the following format: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
 		if stepHandlersErrors.ApproveSdcError {
@@ -1834,6 +1898,8 @@ func handleInstances(w http.ResponseWriter, r *http.Request) {
 				replacementMap["__CONSISTENCY_GROUP_ID__"] = vol["consistencyGroupID"]
 				replacementMap["__SIZE_IN_KB__"] = vol["sizeInKb"]
 				replacementMap["__VOLUME_REPLICATION_STATE__"] = vol["volumeReplicationState"]
+				replacementMap["__SNAPSHOT_POLICY_ID__"] = volumeIDToSnapshotPolicyID[id]
+				replacementMap["__AUTO_SNAPSHOT_POLICY_ID__"] = volumeIDToAutoSnapshotPolicyID[id]
 			} else {
 				replacementMap["__ID__"] = id
 				replacementMap["__NAME__"] = volumeIDToName[id]
@@ -1842,6 +1908,8 @@ func handleInstances(w http.ResponseWriter, r *http.Request) {
 				replacementMap["__CONSISTENCY_GROUP_ID__"] = volumeIDToConsistencyGroupID[id]
 				replacementMap["__SIZE_IN_KB__"] = volumeIDToSizeInKB[id]
 				replacementMap["__VOLUME_REPLICATION_STATE__"] = volumeIDToReplicationState[id]
+				replacementMap["__SNAPSHOT_POLICY_ID__"] = volumeIDToSnapshotPolicyID[id]
+				replacementMap["__AUTO_SNAPSHOT_POLICY_ID__"] = volumeIDToAutoSnapshotPolicyID[id]
 			}
 			returnJSONFile("features", "volume.json.template", w, replacementMap)
 		} else {
@@ -0,0 +1,177 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	compressionNone   = "None"
+	compressionNormal = "Normal"
+
+	accessModeReadWrite = "ReadWrite"
+	accessModeReadOnly  = "ReadOnly"
+
+	poolLayoutFineGranularity = "FineGranularity"
+
+	// minCompressionVersion is the first PowerFlex version with fine granularity pools
+	minCompressionVersion = 3.0
+
+	setAccessModeLimitActionFormat = "/api/instances/Volume::%s/action/setVolumeAccessModeLimit"
+)
+
+// volumeFeatures are the optional volume settings requested in the StorageClass.
+// Empty values leave the array defaults.
+type volumeFeatures struct {
+	compressionMethod string
+	useRmCache        string
+	accessModeLimit   string
+}
+
+// hasVolumeFeatures returns true when any of the volume feature parameters is set
+func hasVolumeFeatures(params map[string]string) bool {
+	for _, key := range []string{KeyCompressionMethod, KeyUseRmCache, KeyVolumeAccessMode} {
+		if params[key] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getVolumeFeatures parses the volume feature parameters and checks them against the storage
+// pool the volume is created in and the platform of the system. A ReadOnly access mode limit
+// needs a content source: a new empty volume could never be formatted.
+func getVolumeFeatures(params map[string]string, pool *siotypes.StoragePool, platformInfo *PlatformInfo, volType string, hasContentSource bool) (*volumeFeatures, error) {
+	features := &volumeFeatures{}
+
+	if method := params[KeyCompressionMethod]; method != "" {
+		switch {
+		case strings.EqualFold(method, compressionNone):
+			features.compressionMethod = compressionNone
+		case strings.EqualFold(method, compressionNormal):
+			features.compressionMethod = compressionNormal
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %s, allowed values are %s and %s",
+				KeyCompressionMethod, method, compressionNone, compressionNormal)
+		}
+		if features.compressionMethod == compressionNormal {
+			if platformInfo.GenType == "EC" {
+				return nil, status.Errorf(codes.InvalidArgument, "%s is not supported on system %s with GenType %s",
+					KeyCompressionMethod, platformInfo.SystemID, platformInfo.GenType)
+			}
+			if platformInfo.ArrayVersion < minCompressionVersion {
+				return nil, status.Errorf(codes.InvalidArgument, "compression is not supported on system %s PowerFlex version %.1f",
+					platformInfo.SystemID, platformInfo.ArrayVersion)
+			}
+			if pool.DataLayout != poolLayoutFineGranularity {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"compression requires a fine granularity storage pool, storage pool %s is %s", pool.Name, pool.DataLayout)
+			}
+			if volType == thickProvisioned {
+				return nil, status.Error(codes.InvalidArgument, "compressed volumes must be thin provisioned")
+			}
+		}
+	}
+
+	if rmCache := params[KeyUseRmCache]; rmCache != "" {
+		useRmCache, err := strconv.ParseBool(rmCache)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %s, must be true or false", KeyUseRmCache, rmCache)
+		}
+		if useRmCache {
+			if platformInfo.GenType == "EC" {
+				return nil, status.Errorf(codes.InvalidArgument, "%s is not supported on system %s with GenType %s",
+					KeyUseRmCache, platformInfo.SystemID, platformInfo.GenType)
+			}
+			if pool.DataLayout == poolLayoutFineGranularity {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"RAM read cache is not supported on fine granularity storage pool %s", pool.Name)
+			}
+			if !pool.UseRmcache {
+				return nil, status.Errorf(codes.InvalidArgument, "RAM read cache is not enabled on storage pool %s", pool.Name)
+			}
+		}
+		features.useRmCache = strconv.FormatBool(useRmCache)
+	}
+
+	if accessMode := params[KeyVolumeAccessMode]; accessMode != "" {
+		switch {
+		case strings.EqualFold(accessMode, accessModeReadWrite):
+			features.accessModeLimit = accessModeReadWrite
+		case strings.EqualFold(accessMode, accessModeReadOnly):
+			if !hasContentSource {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"%s %s requires a volume created from a snapshot or a clone, a new empty volume could not be formatted",
+					KeyVolumeAccessMode, accessModeReadOnly)
+			}
+			features.accessModeLimit = accessModeReadOnly
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %s, allowed values are %s and %s",
+				KeyVolumeAccessMode, accessMode, accessModeReadWrite, accessModeReadOnly)
+		}
+	}
+
+	return features, nil
+}
+
+// validateVolumeFeatures looks up the storage pool and platform of the system and validates the
+// volume feature parameters. It returns nil when none is set.
+func (s *service) validateVolumeFeatures(params map[string]string, systemID, storagePool, pdID, volType string, hasContentSource bool) (*volumeFeatures, error) {
+	if !hasVolumeFeatures(params) {
+		return nil, nil
+	}
+	platformInfo, err := s.GetPlatformInfo(systemID)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := s.adminClients[systemID].FindStoragePool("", storagePool, "", pdID)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "storage pool %s not found: %s", storagePool, err.Error())
+	}
+	return getVolumeFeatures(params, pool, platformInfo, volType, hasContentSource)
+}
+
+// apply sets the features passed at volume creation
+func (f *volumeFeatures) apply(volumeParam *siotypes.VolumeParam) {
+	if f == nil {
+		return
+	}
+	volumeParam.CompressionMethod = f.compressionMethod
+	volumeParam.UseRmCache = f.useRmCache
+}
+
+// setAccessModeLimit limits the access mode of a volume created from a snapshot or a clone, which
+// can only be done once it exists. Volumes are ReadWrite already.
+func (s *service) setAccessModeLimit(ctx context.Context, systemID string, csiVolID string, features *volumeFeatures) error {
+	if features == nil || features.accessModeLimit != accessModeReadOnly {
+		return nil
+	}
+	volID := getVolumeIDFromCsiVolumeID(csiVolID)
+	err := s.arrayRESTRequest(ctx, systemID, http.MethodPost, fmt.Sprintf(setAccessModeLimitActionFormat, volID),
+		map[string]string{"accessModeLimit": features.accessModeLimit}, nil)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error setting access mode limit %s of volume %s: %s",
+			features.accessModeLimit, volID, err.Error())
+	}
+	return nil
+}
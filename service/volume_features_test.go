@@ -0,0 +1,140 @@
+// Copyright © 2026 Dell Inc. or its subsidiaries. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package service
+
+import (
+	"testing"
+
+	siotypes "github.com/dell/goscaleio/types/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVolumeFeatures(t *testing.T) {
+	fgPool := &siotypes.StoragePool{Name: "fg", DataLayout: poolLayoutFineGranularity}
+	mgPool := &siotypes.StoragePool{Name: "mg", DataLayout: "MediumGranularity", UseRmcache: true}
+	mgPoolNoCache := &siotypes.StoragePool{Name: "mg2", DataLayout: "MediumGranularity"}
+	v4 := &PlatformInfo{SystemID: "sys1", ArrayVersion: 4.5}
+	ec := &PlatformInfo{SystemID: "sys1", ArrayVersion: 5.0, GenType: "EC"}
+
+	tests := []struct {
+		name     string
+		params   map[string]string
+		pool     *siotypes.StoragePool
+		platform *PlatformInfo
+		volType  string
+		source   bool
+		want     *volumeFeatures
+		wantErr  bool
+	}{
+		{
+			name:     "compression on fine granularity pool",
+			params:   map[string]string{KeyCompressionMethod: "normal"},
+			pool:     fgPool,
+			platform: v4,
+			volType:  thinProvisioned,
+			want:     &volumeFeatures{compressionMethod: compressionNormal},
+		},
+		{
+			name:     "compression on medium granularity pool",
+			params:   map[string]string{KeyCompressionMethod: compressionNormal},
+			pool:     mgPool,
+			platform: v4,
+			volType:  thinProvisioned,
+			wantErr:  true,
+		},
+		{
+			name:     "compression of thick volume",
+			params:   map[string]string{KeyCompressionMethod: compressionNormal},
+			pool:     fgPool,
+			platform: v4,
+			volType:  thickProvisioned,
+			wantErr:  true,
+		},
+		{
+			name:     "compression on EC system",
+			params:   map[string]string{KeyCompressionMethod: compressionNormal},
+			pool:     fgPool,
+			platform: ec,
+			volType:  thinProvisioned,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid compression method",
+			params:   map[string]string{KeyCompressionMethod: "zstd"},
+			pool:     fgPool,
+			platform: v4,
+			wantErr:  true,
+		},
+		{
+			name:     "RAM read cache",
+			params:   map[string]string{KeyUseRmCache: "true", KeyVolumeAccessMode: "readonly"},
+			pool:     mgPool,
+			platform: v4,
+			source:   true,
+			want:     &volumeFeatures{useRmCache: "true", accessModeLimit: accessModeReadOnly},
+		},
+		{
+			name:     "read only new empty volume",
+			params:   map[string]string{KeyVolumeAccessMode: accessModeReadOnly},
+			pool:     mgPool,
+			platform: v4,
+			wantErr:  true,
+		},
+		{
+			name:     "RAM read cache disabled on pool",
+			params:   map[string]string{KeyUseRmCache: "true"},
+			pool:     mgPoolNoCache,
+			platform: v4,
+			wantErr:  true,
+		},
+		{
+			name:     "RAM read cache on fine granularity pool",
+			params:   map[string]string{KeyUseRmCache: "true"},
+			pool:     fgPool,
+			platform: v4,
+			wantErr:  true,
+		},
+		{
+			name:     "no RAM read cache",
+			params:   map[string]string{KeyUseRmCache: "false"},
+			pool:     fgPool,
+			platform: ec,
+			want:     &volumeFeatures{useRmCache: "false"},
+		},
+		{
+			name:     "invalid access mode",
+			params:   map[string]string{KeyVolumeAccessMode: "WriteOnly"},
+			pool:     mgPool,
+			platform: v4,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getVolumeFeatures(tt.params, tt.pool, tt.platform, tt.volType, tt.source)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHasVolumeFeatures(t *testing.T) {
+	assert.False(t, hasVolumeFeatures(map[string]string{KeyStoragePool: "pool1"}))
+	assert.True(t, hasVolumeFeatures(map[string]string{KeyUseRmCache: "false"}))
+}